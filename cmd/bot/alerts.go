@@ -0,0 +1,199 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	db "financial-chat-api/db/sqlc"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+var alertOperators = map[string]bool{">": true, ">=": true, "<": true, "<=": true}
+
+// triggered reports whether price crossed the alert threshold
+func triggered(a db.Alert, price float64) bool {
+	switch a.Operator {
+	case ">":
+		return price > a.Threshold
+	case ">=":
+		return price >= a.Threshold
+	case "<":
+		return price < a.Threshold
+	case "<=":
+		return price <= a.Threshold
+	}
+	return false
+}
+
+func formatAlert(a db.Alert) string {
+	return fmt.Sprintf("#%d %s %s %s (by %s)",
+		a.ID, strings.ToUpper(a.Symbol), a.Operator, strconv.FormatFloat(a.Threshold, 'f', -1, 64), a.Username)
+}
+
+func roomUUID(roomId string) (pgtype.UUID, error) {
+	id, err := uuid.Parse(roomId)
+	if err != nil {
+		return pgtype.UUID{}, fmt.Errorf("invalid room id %q: %w", roomId, err)
+	}
+	return pgtype.UUID{Bytes: id, Valid: true}, nil
+}
+
+func (b *bot) alertCommand(ctx context.Context, m botMessage, args string) (reply, error) {
+	fields := strings.Fields(args)
+	if len(fields) != 3 {
+		return reply{}, usage("/alert <symbol> <>|>=|<|<=> <price>")
+	}
+
+	symbol, operator := strings.ToLower(fields[0]), fields[1]
+	if !alertOperators[operator] {
+		return reply{}, usage("/alert <symbol> <>|>=|<|<=> <price>")
+	}
+	threshold, err := parseNumber(fields[2])
+	if err != nil {
+		return reply{}, fmt.Errorf("%w: invalid price %q", errInvalidArgs, fields[2])
+	}
+
+	roomId, err := roomUUID(m.RoomId)
+	if err != nil {
+		return reply{}, err
+	}
+
+	// fail early on symbols stooq doesn't know instead of polling them forever
+	if _, err := b.provider.Quote(ctx, symbol); err != nil {
+		return reply{}, err
+	}
+
+	alert, err := b.store.CreateAlert(ctx, db.CreateAlertParams{
+		RoomID:    roomId,
+		Username:  m.Username,
+		Symbol:    symbol,
+		Operator:  operator,
+		Threshold: threshold,
+	})
+	if err != nil {
+		return reply{}, err
+	}
+
+	return reply{Msg: "alert set: " + formatAlert(alert), Data: alert}, nil
+}
+
+func (b *bot) alertsCommand(ctx context.Context, m botMessage, args string) (reply, error) {
+	roomId, err := roomUUID(m.RoomId)
+	if err != nil {
+		return reply{}, err
+	}
+
+	alerts, err := b.store.ListRoomAlerts(ctx, roomId)
+	if err != nil {
+		return reply{}, err
+	}
+	if len(alerts) == 0 {
+		return reply{Msg: "no active alerts in this room", Data: alerts}, nil
+	}
+
+	lines := make([]string, len(alerts))
+	for i, a := range alerts {
+		lines[i] = formatAlert(a)
+	}
+	return reply{Msg: "active alerts: " + strings.Join(lines, ", "), Data: alerts}, nil
+}
+
+func (b *bot) alertCancelCommand(ctx context.Context, m botMessage, args string) (reply, error) {
+	id, err := strconv.ParseInt(strings.TrimPrefix(args, "#"), 10, 64)
+	if err != nil {
+		return reply{}, usage("/alert-cancel <id>")
+	}
+
+	roomId, err := roomUUID(m.RoomId)
+	if err != nil {
+		return reply{}, err
+	}
+
+	deleted, err := b.store.DeleteAlert(ctx, db.DeleteAlertParams{ID: id, RoomID: roomId, Username: m.Username})
+	if err != nil {
+		return reply{}, err
+	}
+	if deleted == 0 {
+		return reply{}, fmt.Errorf("%w: you have no active alert #%d in this room", errInvalidArgs, id)
+	}
+
+	return reply{Msg: fmt.Sprintf("alert #%d cancelled", id)}, nil
+}
+
+// runAlerts polls the quotes of every active alert each interval and posts
+// the ones that triggered into their room
+func (b *bot) runAlerts(ch *amqp.Channel, interval time.Duration) {
+	log.Println("alerts running...")
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		b.checkAlerts(ch)
+	}
+}
+
+func (b *bot) checkAlerts(ch *amqp.Channel) {
+	alerts, err := b.listActiveAlerts()
+	if err != nil {
+		log.Println("error listing alerts: ", err)
+		return
+	}
+
+	quotes := make(map[string]quote)
+	for _, a := range alerts {
+		q, ok := quotes[a.Symbol]
+		if !ok {
+			q, err = b.alertQuote(a.Symbol)
+			if err != nil {
+				log.Printf("error getting quote for alert #%d: %s", a.ID, err)
+				continue
+			}
+			quotes[a.Symbol] = q
+		}
+
+		if !triggered(a, q.Close) {
+			continue
+		}
+
+		b.fireAlert(ch, a, q)
+	}
+}
+
+func (b *bot) listActiveAlerts() ([]db.Alert, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), b.requestTimeout)
+	defer cancel()
+	return b.store.ListActiveAlerts(ctx)
+}
+
+// alertQuote fetches with its own timeout, a slow symbol doesn't eat into the others
+func (b *bot) alertQuote(symbol string) (quote, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), b.requestTimeout)
+	defer cancel()
+	return b.provider.Quote(ctx, symbol)
+}
+
+// fireAlert marks the alert fired before posting it, so a failure can only
+// lose the post, never repeat it on every tick
+func (b *bot) fireAlert(ch *amqp.Channel, a db.Alert, q quote) {
+	ctx, cancel := context.WithTimeout(context.Background(), b.requestTimeout)
+	defer cancel()
+
+	err := b.store.MarkAlertFired(ctx, a.ID)
+	if err != nil {
+		log.Printf("error marking alert #%d as fired: %s", a.ID, err)
+		return
+	}
+
+	b.publish(ctx, ch, &botMessage{
+		RoomId: uuid.UUID(a.RoomID.Bytes).String(),
+		Msg:    fmt.Sprintf("ALERT %s triggered, %s is at $%.2f", formatAlert(a), strings.ToUpper(a.Symbol), q.Close),
+		Data:   a,
+	})
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"context"
+	"expvar"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+)
+
+var (
+	cacheHits   = expvar.NewInt("quote_cache_hits")
+	cacheMisses = expvar.NewInt("quote_cache_misses")
+)
+
+// cacheVars serves the cache counters in the format of expvar
+func cacheVars(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	fmt.Fprintf(w, "{\n%q: %s,\n%q: %s\n}\n", "quote_cache_hits", cacheHits, "quote_cache_misses", cacheMisses)
+}
+
+type cacheEntry struct {
+	quote     quote
+	expiresAt time.Time
+}
+
+// cachedProvider keeps quotes in memory for ttl and coalesces concurrent
+// lookups of the same symbol into a single call to the wrapped provider
+type cachedProvider struct {
+	provider quoteProvider
+	ttl      time.Duration
+	// timeout bounds the shared lookup, which doesn't belong to any single caller
+	timeout time.Duration
+	mu      sync.RWMutex
+	entries map[string]cacheEntry
+	group   singleflight.Group
+}
+
+func newCachedProvider(provider quoteProvider, ttl time.Duration, timeout time.Duration) *cachedProvider {
+	return &cachedProvider{
+		provider: provider,
+		ttl:      ttl,
+		timeout:  timeout,
+		entries:  make(map[string]cacheEntry),
+	}
+}
+
+func (c *cachedProvider) Quote(ctx context.Context, symbol string) (quote, error) {
+	key := strings.ToLower(symbol)
+
+	if q, ok := c.get(key); ok {
+		cacheHits.Add(1)
+		return q, nil
+	}
+	cacheMisses.Add(1)
+
+	// the first caller giving up must not fail the others waiting on the same key
+	ch := c.group.DoChan(key, func() (interface{}, error) {
+		fetchCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), c.timeout)
+		defer cancel()
+
+		q, err := c.provider.Quote(fetchCtx, key)
+		if err != nil {
+			return quote{}, err
+		}
+		c.set(key, q)
+		return q, nil
+	})
+
+	select {
+	case res := <-ch:
+		if res.Err != nil {
+			return quote{}, res.Err
+		}
+		return res.Val.(quote), nil
+	case <-ctx.Done():
+		return quote{}, ctx.Err()
+	}
+}
+
+func (c *cachedProvider) get(key string) (quote, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	entry, ok := c.entries[key]
+	if !ok || time.Now().After(entry.expiresAt) {
+		return quote{}, false
+	}
+	return entry.quote, true
+}
+
+func (c *cachedProvider) set(key string, q quote) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	for k, entry := range c.entries {
+		if now.After(entry.expiresAt) {
+			delete(c.entries, k)
+		}
+	}
+	c.entries[key] = cacheEntry{quote: q, expiresAt: now.Add(c.ttl)}
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// countingProvider counts the lookups that reach it, holding them until
+// release is closed when it is set
+type countingProvider struct {
+	calls   atomic.Int32
+	release chan struct{}
+}
+
+func (p *countingProvider) Quote(ctx context.Context, symbol string) (quote, error) {
+	p.calls.Add(1)
+	if p.release != nil {
+		select {
+		case <-p.release:
+		case <-ctx.Done():
+			return quote{}, ctx.Err()
+		}
+	}
+	return quote{Symbol: symbol, Close: 1}, nil
+}
+
+// waitCalls waits until n lookups reached the provider
+func (p *countingProvider) waitCalls(t *testing.T, n int32) {
+	t.Helper()
+
+	deadline := time.Now().Add(time.Second)
+	for p.calls.Load() < n {
+		if time.Now().After(deadline) {
+			t.Fatalf("got %d calls, want %d", p.calls.Load(), n)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestCachedProviderExpires(t *testing.T) {
+	provider := &countingProvider{}
+	cache := newCachedProvider(provider, 50*time.Millisecond, time.Second)
+	ctx := context.Background()
+
+	for _, symbol := range []string{"aapl.us", "AAPL.US", "aapl.us"} {
+		q, err := cache.Quote(ctx, symbol)
+		if err != nil || q.Symbol != "aapl.us" {
+			t.Fatalf("Quote(%q) = %+v, %v", symbol, q, err)
+		}
+	}
+	if calls := provider.calls.Load(); calls != 1 {
+		t.Fatalf("got %d calls within the ttl, want 1", calls)
+	}
+
+	time.Sleep(60 * time.Millisecond)
+	_, err := cache.Quote(ctx, "aapl.us")
+	if err != nil {
+		t.Fatalf("Quote after the ttl: %v", err)
+	}
+	if calls := provider.calls.Load(); calls != 2 {
+		t.Fatalf("got %d calls after the ttl, want 2", calls)
+	}
+}
+
+func TestCachedProviderCoalescesLookups(t *testing.T) {
+	provider := &countingProvider{release: make(chan struct{})}
+	cache := newCachedProvider(provider, time.Minute, time.Second)
+
+	const callers = 20
+	var wg sync.WaitGroup
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := cache.Quote(context.Background(), "msft.us")
+			if err != nil {
+				t.Errorf("Quote: %v", err)
+			}
+		}()
+	}
+
+	// callers that come after the lookup finished hit the cache instead
+	provider.waitCalls(t, 1)
+	close(provider.release)
+	wg.Wait()
+
+	if calls := provider.calls.Load(); calls != 1 {
+		t.Fatalf("got %d calls for %d concurrent callers, want 1", calls, callers)
+	}
+}
+
+func TestCachedProviderCallerGivingUpDoesNotFailOthers(t *testing.T) {
+	provider := &countingProvider{release: make(chan struct{})}
+	cache := newCachedProvider(provider, time.Minute, time.Second)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	first := make(chan error, 1)
+	go func() {
+		_, err := cache.Quote(ctx, "msft.us")
+		first <- err
+	}()
+	provider.waitCalls(t, 1)
+
+	second := make(chan error, 1)
+	go func() {
+		_, err := cache.Quote(context.Background(), "msft.us")
+		second <- err
+	}()
+
+	cancel()
+	if err := <-first; !errors.Is(err, context.Canceled) {
+		t.Fatalf("first caller got %v, want context.Canceled", err)
+	}
+
+	close(provider.release)
+	if err := <-second; err != nil {
+		t.Fatalf("second caller got %v", err)
+	}
+	if calls := provider.calls.Load(); calls != 1 {
+		t.Fatalf("got %d calls, want 1", calls)
+	}
+}
+
+func TestCacheVarsOnlyServesTheCounters(t *testing.T) {
+	w := httptest.NewRecorder()
+	cacheVars(w, httptest.NewRequest("GET", "/debug/vars", nil))
+
+	var vars map[string]int64
+	err := json.Unmarshal(w.Body.Bytes(), &vars)
+	if err != nil {
+		t.Fatalf("decode %q: %v", w.Body.String(), err)
+	}
+	if _, ok := vars["quote_cache_hits"]; !ok || len(vars) != 2 {
+		t.Fatalf("got %v, want the hits and misses only", vars)
+	}
+}
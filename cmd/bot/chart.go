@@ -0,0 +1,115 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"html"
+	"strings"
+	"time"
+)
+
+const (
+	chartWidth   = 600
+	chartHeight  = 240
+	chartPadding = 40
+)
+
+var sparks = []rune("▁▂▃▄▅▆▇█")
+
+// attachment is a file posted along a bot reply, Data is base64 encoded in json
+type attachment struct {
+	Name        string `json:"name"`
+	ContentType string `json:"contentType"`
+	Data        []byte `json:"data"`
+}
+
+// sparkline draws closes as a line of block characters, for clients that
+// can't render the svg
+func sparkline(bars []bar) string {
+	low, high := bars[0].Close, bars[0].Close
+	for _, b := range bars {
+		low, high = min(low, b.Close), max(high, b.Close)
+	}
+
+	var sb strings.Builder
+	for _, b := range bars {
+		i := 0
+		if high > low {
+			i = int((b.Close - low) / (high - low) * float64(len(sparks)-1))
+		}
+		sb.WriteRune(sparks[i])
+	}
+	return sb.String()
+}
+
+// renderSVG draws the closes as a line chart with the range and price bounds as labels
+func renderSVG(s historySummary, bars []bar) []byte {
+	low, high := bars[0].Close, bars[0].Close
+	for _, b := range bars {
+		low, high = min(low, b.Close), max(high, b.Close)
+	}
+	spread := high - low
+	if spread == 0 {
+		spread = 1
+	}
+
+	plotWidth := float64(chartWidth - 2*chartPadding)
+	plotHeight := float64(chartHeight - 2*chartPadding)
+	points := make([]string, len(bars))
+	for i, b := range bars {
+		x := float64(chartPadding)
+		if len(bars) > 1 {
+			x += float64(i) / float64(len(bars)-1) * plotWidth
+		}
+		y := float64(chartPadding) + (high-b.Close)/spread*plotHeight
+		points[i] = fmt.Sprintf("%.1f,%.1f", x, y)
+	}
+
+	color := "#2e7d32"
+	if s.LastClose < s.FirstClose {
+		color = "#c62828"
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" font-family="sans-serif" font-size="12">`,
+		chartWidth, chartHeight, chartWidth, chartHeight)
+	fmt.Fprintf(&buf, `<rect width="100%%" height="100%%" fill="#ffffff"/>`)
+	fmt.Fprintf(&buf, `<text x="%d" y="24" font-size="14" font-weight="bold">%s %+.2f%%</text>`,
+		chartPadding, html.EscapeString(s.Symbol), s.ChangePercent)
+	fmt.Fprintf(&buf, `<text x="%d" y="%d" text-anchor="end">%.2f</text>`, chartWidth-4, chartPadding+4, high)
+	fmt.Fprintf(&buf, `<text x="%d" y="%d" text-anchor="end">%.2f</text>`, chartWidth-4, chartHeight-chartPadding+4, low)
+	fmt.Fprintf(&buf, `<text x="%d" y="%d">%s</text>`, chartPadding, chartHeight-12, s.From)
+	fmt.Fprintf(&buf, `<text x="%d" y="%d" text-anchor="end">%s</text>`, chartWidth-chartPadding, chartHeight-12, s.To)
+	fmt.Fprintf(&buf, `<polyline fill="none" stroke="%s" stroke-width="2" points="%s"/>`, color, strings.Join(points, " "))
+	buf.WriteString(`</svg>`)
+	return buf.Bytes()
+}
+
+func (b *bot) chartCommand(ctx context.Context, m botMessage, args string) (reply, error) {
+	symbol, span := splitSymbolRange(args)
+	if symbol == "" {
+		return reply{}, usage("/chart=<symbol> [1w|1m|1y|2024-01-01..2024-03-31]")
+	}
+
+	from, to, err := parseRange(span, time.Now())
+	if err != nil {
+		return reply{}, err
+	}
+
+	bars, err := b.history.History(ctx, symbol, from, to)
+	if err != nil {
+		return reply{}, err
+	}
+
+	summary := summarize(symbol, bars)
+	return reply{
+		Msg:  fmt.Sprintf("%s %s..%s %s", summary.Symbol, summary.From, summary.To, sparkline(bars)),
+		Data: summary,
+		Attachment: &attachment{
+			Name:        fmt.Sprintf("%s_%s_%s.svg", strings.ToLower(symbol), summary.From, summary.To),
+			ContentType: "image/svg+xml",
+			Data:        renderSVG(summary, bars),
+		},
+	}, nil
+}
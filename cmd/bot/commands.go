@@ -0,0 +1,120 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var errInvalidArgs = errors.New("invalid arguments")
+
+// reply is what a command answers to the room, Data carries the structured
+// version of Msg for clients that want to render it
+type reply struct {
+	Msg        string
+	Data       any
+	Attachment *attachment
+}
+
+type commandHandler func(ctx context.Context, m botMessage, args string) (reply, error)
+
+func (b *bot) registerCommands() {
+	b.commands = map[string]commandHandler{
+		"stock":        b.stockCommand,
+		"history":      b.historyCommand,
+		"alert":        b.alertCommand,
+		"alerts":       b.alertsCommand,
+		"alert-cancel": b.alertCancelCommand,
+		"watch":        b.watchCommand,
+		"fx":           b.fxCommand,
+		"convert":      b.convertCommand,
+		"chart":        b.chartCommand,
+		"buy":          b.buyCommand,
+		"sell":         b.sellCommand,
+		"portfolio":    b.portfolioCommand,
+		"search":       b.searchCommand,
+		"compare":      b.compareCommand,
+		"change":       b.changeCommand,
+	}
+}
+
+// parseCommand splits "/name=args" or "/name args" into its name and arguments
+func parseCommand(msg string) (string, string, bool) {
+	msg = strings.TrimSpace(msg)
+	if !strings.HasPrefix(msg, "/") {
+		return "", "", false
+	}
+	msg = strings.TrimPrefix(msg, "/")
+
+	i := strings.IndexAny(msg, "= ")
+	if i < 0 {
+		return strings.ToLower(msg), "", true
+	}
+	return strings.ToLower(msg[:i]), strings.TrimSpace(msg[i+1:]), true
+}
+
+func (b *bot) handle(ctx context.Context, m botMessage) (reply, bool, error) {
+	name, args, ok := parseCommand(m.Msg)
+	if !ok {
+		return reply{}, false, nil
+	}
+
+	handler, ok := b.commands[name]
+	if !ok {
+		return reply{}, false, nil
+	}
+
+	r, err := handler(ctx, m, args)
+	return r, true, err
+}
+
+func usage(format string) error {
+	return fmt.Errorf("%w, usage: %s", errInvalidArgs, format)
+}
+
+// parseNumber reads a command argument, ParseFloat also takes NaN and Inf which
+// no quantity, price or amount can be
+func parseNumber(s string) (float64, error) {
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0, err
+	}
+	if math.IsNaN(v) || math.IsInf(v, 0) {
+		return 0, fmt.Errorf("%q is not a finite number", s)
+	}
+	return v, nil
+}
+
+type stockReply struct {
+	quote
+	Market *marketStatus `json:"market,omitempty"`
+}
+
+func (b *bot) stockCommand(ctx context.Context, m botMessage, args string) (reply, error) {
+	if args == "" {
+		return reply{}, usage("/stock=<symbol>")
+	}
+
+	q, err := b.provider.Quote(ctx, args)
+	if errors.Is(err, errNoData) {
+		if suggestions := b.symbols.suggest(args, suggestionLimit); len(suggestions) > 0 {
+			return reply{}, fmt.Errorf("%w for %s, did you mean %s?", errNoData, args, formatEntries(suggestions))
+		}
+		return reply{}, fmt.Errorf("%w for %s, try /search <company name>", errNoData, args)
+	}
+	if err != nil {
+		return reply{}, err
+	}
+
+	msg := formatQuote(q)
+	data := stockReply{quote: q}
+	if status, ok := b.calendars.status(q, time.Now()); ok {
+		msg += " (" + status.String() + ")"
+		data.Market = &status
+	}
+	return reply{Msg: msg, Data: data}, nil
+}
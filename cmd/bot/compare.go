@@ -0,0 +1,139 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const maxCompareSymbols = 10
+
+type dayChange struct {
+	Symbol        string  `json:"symbol"`
+	Date          string  `json:"date"`
+	Price         float64 `json:"price"`
+	PreviousClose float64 `json:"previousClose"`
+	Change        float64 `json:"change"`
+	ChangePercent float64 `json:"changePercent"`
+}
+
+type comparison struct {
+	From        string           `json:"from"`
+	To          string           `json:"to"`
+	Performance []historySummary `json:"performance"`
+	Missing     []string         `json:"missing"`
+}
+
+func (b *bot) compareCommand(ctx context.Context, m botMessage, args string) (reply, error) {
+	const compareUsage = "/compare <symbol> <symbol>... [1w|1m|1y|2024-01-01..2024-03-31]"
+
+	symbols := strings.Fields(strings.ToLower(args))
+	span := defaultSpan
+	if len(symbols) > 0 {
+		last := symbols[len(symbols)-1]
+		if _, _, err := parseRange(last, time.Now()); err == nil {
+			span, symbols = last, symbols[:len(symbols)-1]
+		}
+	}
+	if len(symbols) < 2 || len(symbols) > maxCompareSymbols {
+		return reply{}, usage(compareUsage)
+	}
+
+	from, to, err := parseRange(span, time.Now())
+	if err != nil {
+		return reply{}, err
+	}
+
+	summaries := make([]historySummary, len(symbols))
+	errs := make([]error, len(symbols))
+	var wg sync.WaitGroup
+	for i, symbol := range symbols {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			bars, err := b.history.History(ctx, symbol, from, to)
+			if err != nil {
+				errs[i] = err
+				return
+			}
+			summaries[i] = summarize(symbol, bars)
+		}()
+	}
+	wg.Wait()
+
+	res := comparison{From: from.Format(dateLayout), To: to.Format(dateLayout), Performance: []historySummary{}, Missing: []string{}}
+	for i, symbol := range symbols {
+		if errs[i] != nil {
+			if !errors.Is(errs[i], errNoData) {
+				return reply{}, errs[i]
+			}
+			res.Missing = append(res.Missing, symbol)
+			continue
+		}
+		res.Performance = append(res.Performance, summaries[i])
+	}
+	if len(res.Performance) == 0 {
+		return reply{}, fmt.Errorf("%w for %s", errNoData, strings.Join(symbols, ", "))
+	}
+
+	sort.SliceStable(res.Performance, func(i, j int) bool {
+		return res.Performance[i].ChangePercent > res.Performance[j].ChangePercent
+	})
+
+	entries := make([]string, 0, len(symbols))
+	for _, s := range res.Performance {
+		entries = append(entries, fmt.Sprintf("%s %+.2f%%", s.Symbol, s.ChangePercent))
+	}
+	for _, symbol := range res.Missing {
+		entries = append(entries, strings.ToUpper(symbol)+" n/a")
+	}
+	msg := fmt.Sprintf("performance %s..%s: %s", res.From, res.To, strings.Join(entries, ", "))
+	return reply{Msg: msg, Data: res}, nil
+}
+
+func (b *bot) changeCommand(ctx context.Context, m botMessage, args string) (reply, error) {
+	symbol := strings.ToLower(strings.TrimSpace(args))
+	if symbol == "" || strings.Contains(symbol, " ") {
+		return reply{}, usage("/change <symbol>")
+	}
+
+	q, err := b.provider.Quote(ctx, symbol)
+	if err != nil {
+		return reply{}, err
+	}
+
+	// enough days back to get past weekends and holidays
+	now := time.Now()
+	bars, err := b.history.History(ctx, symbol, now.AddDate(0, 0, -10), now)
+	if err != nil {
+		return reply{}, err
+	}
+
+	// the previous close is the last daily bar before the day of the quote
+	var previous *bar
+	for i := len(bars) - 1; i >= 0; i-- {
+		if bars[i].Date.Format(dateLayout) < q.Date {
+			previous = &bars[i]
+			break
+		}
+	}
+	if previous == nil || previous.Close == 0 {
+		return reply{}, fmt.Errorf("%w: no previous close for %s", errNoData, symbol)
+	}
+
+	c := dayChange{
+		Symbol:        strings.ToUpper(q.Symbol),
+		Date:          q.Date,
+		Price:         q.Close,
+		PreviousClose: previous.Close,
+		Change:        q.Close - previous.Close,
+		ChangePercent: (q.Close - previous.Close) / previous.Close * 100,
+	}
+	msg := fmt.Sprintf("%s is at $%.2f, %+.2f (%+.2f%%) vs previous close $%.2f",
+		c.Symbol, c.Price, c.Change, c.ChangePercent, c.PreviousClose)
+	return reply{Msg: msg, Data: c}, nil
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var currencyPattern = regexp.MustCompile(`^[a-z]{3}$`)
+
+type fxRate struct {
+	From string  `json:"from"`
+	To   string  `json:"to"`
+	Rate float64 `json:"rate"`
+	Date string  `json:"date"`
+	Time string  `json:"time"`
+}
+
+type conversion struct {
+	fxRate
+	Amount    float64 `json:"amount"`
+	Converted float64 `json:"converted"`
+}
+
+// parsePair reads a currency pair written as usdeur, usd/eur or usd eur
+func parsePair(args string) (string, string, bool) {
+	args = strings.ToLower(strings.Join(strings.FieldsFunc(args, func(r rune) bool {
+		return r == ' ' || r == '/'
+	}), ""))
+	if len(args) != 6 {
+		return "", "", false
+	}
+
+	from, to := args[:3], args[3:]
+	if !currencyPattern.MatchString(from) || !currencyPattern.MatchString(to) {
+		return "", "", false
+	}
+	return from, to, true
+}
+
+// rate quotes the from/to pair, falling back to the inverse of to/from
+// since stooq only lists one direction for most pairs
+func (b *bot) rate(ctx context.Context, from, to string) (fxRate, error) {
+	if from == to {
+		return fxRate{From: from, To: to, Rate: 1}, nil
+	}
+
+	q, err := b.provider.Quote(ctx, from+to)
+	if err == nil {
+		return fxRate{From: from, To: to, Rate: q.Close, Date: q.Date, Time: q.Time}, nil
+	}
+	if !errors.Is(err, errNoData) {
+		return fxRate{}, err
+	}
+
+	q, err = b.provider.Quote(ctx, to+from)
+	if err != nil {
+		if errors.Is(err, errNoData) {
+			return fxRate{}, fmt.Errorf("%w for %s/%s", errNoData, strings.ToUpper(from), strings.ToUpper(to))
+		}
+		return fxRate{}, err
+	}
+	if q.Close == 0 {
+		return fxRate{}, fmt.Errorf("%w for %s/%s", errNoData, strings.ToUpper(from), strings.ToUpper(to))
+	}
+	return fxRate{From: from, To: to, Rate: 1 / q.Close, Date: q.Date, Time: q.Time}, nil
+}
+
+func (b *bot) fxCommand(ctx context.Context, m botMessage, args string) (reply, error) {
+	from, to, ok := parsePair(args)
+	if !ok {
+		return reply{}, usage("/fx=<from><to>, like /fx=usdeur")
+	}
+
+	r, err := b.rate(ctx, from, to)
+	if err != nil {
+		return reply{}, err
+	}
+
+	msg := fmt.Sprintf("%s/%s rate is %s", strings.ToUpper(from), strings.ToUpper(to), strconv.FormatFloat(r.Rate, 'f', 4, 64))
+	return reply{Msg: msg, Data: r}, nil
+}
+
+func (b *bot) convertCommand(ctx context.Context, m botMessage, args string) (reply, error) {
+	const convertUsage = "/convert <amount> <from> <to>, like /convert 100 usd eur"
+
+	fields := strings.Fields(strings.ToLower(args))
+	// allow the more natural "/convert 100 usd to eur"
+	if len(fields) == 4 && fields[2] == "to" {
+		fields = append(fields[:2], fields[3])
+	}
+	if len(fields) != 3 {
+		return reply{}, usage(convertUsage)
+	}
+
+	amount, err := parseNumber(fields[0])
+	if err != nil {
+		return reply{}, usage(convertUsage)
+	}
+	from, to, ok := parsePair(fields[1] + fields[2])
+	if !ok {
+		return reply{}, usage(convertUsage)
+	}
+
+	r, err := b.rate(ctx, from, to)
+	if err != nil {
+		return reply{}, err
+	}
+
+	c := conversion{fxRate: r, Amount: amount, Converted: amount * r.Rate}
+	msg := fmt.Sprintf("%.2f %s = %.2f %s (rate %s)",
+		c.Amount, strings.ToUpper(from), c.Converted, strings.ToUpper(to), strconv.FormatFloat(r.Rate, 'f', 4, 64))
+	return reply{Msg: msg, Data: c}, nil
+}
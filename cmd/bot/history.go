@@ -0,0 +1,229 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	historyApi  = "https://stooq.com/q/d/l/"
+	stooqDate   = "20060102"
+	dateLayout  = "2006-01-02"
+	rangeSep    = ".."
+	defaultSpan = "1m"
+)
+
+// bar is a single day of the stooq daily history csv
+type bar struct {
+	Date   time.Time
+	Open   float64
+	High   float64
+	Low    float64
+	Close  float64
+	Volume float64
+}
+
+type historyProvider interface {
+	History(ctx context.Context, symbol string, from, to time.Time) ([]bar, error)
+}
+
+func (p *stooqProvider) History(ctx context.Context, symbol string, from, to time.Time) ([]bar, error) {
+	query := url.Values{}
+	query.Set("s", strings.ToLower(symbol))
+	query.Set("d1", from.Format(stooqDate))
+	query.Set("d2", to.Format(stooqDate))
+	query.Set("i", "d")
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, historyApi+"?"+query.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("stooq responded with status %d", resp.StatusCode)
+	}
+
+	reader := csv.NewReader(resp.Body)
+	// stooq answers a plain "No data" line for unknown symbols or empty ranges
+	reader.FieldsPerRecord = -1
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(records) < 2 {
+		return nil, errNoData
+	}
+
+	bars := make([]bar, 0, len(records)-1)
+	for _, record := range records[1:] {
+		b, err := parseBar(record)
+		if err != nil {
+			return nil, err
+		}
+		bars = append(bars, b)
+	}
+	return bars, nil
+}
+
+// parseBar builds a bar from a Date,Open,High,Low,Close[,Volume] record,
+// volume is missing for indexes and currencies
+func parseBar(record []string) (bar, error) {
+	if len(record) < 5 {
+		return bar{}, errNoData
+	}
+
+	date, err := time.Parse(dateLayout, record[0])
+	if err != nil {
+		return bar{}, fmt.Errorf("invalid date %q in history: %w", record[0], err)
+	}
+
+	var values [5]float64
+	for i, field := range record[1:] {
+		if i >= len(values) {
+			break
+		}
+		v, err := strconv.ParseFloat(field, 64)
+		if err != nil {
+			return bar{}, fmt.Errorf("invalid value %q in history: %w", field, err)
+		}
+		values[i] = v
+	}
+
+	return bar{
+		Date:   date,
+		Open:   values[0],
+		High:   values[1],
+		Low:    values[2],
+		Close:  values[3],
+		Volume: values[4],
+	}, nil
+}
+
+// parseRange accepts a span ending today, like 5d, 2w, 1m or 1y,
+// or an explicit 2024-01-01..2024-03-31 date range
+func parseRange(s string, now time.Time) (time.Time, time.Time, error) {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+
+	if from, to, ok := strings.Cut(s, rangeSep); ok {
+		start, err := time.Parse(dateLayout, from)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("%w: invalid start date %q", errInvalidArgs, from)
+		}
+		end, err := time.Parse(dateLayout, to)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("%w: invalid end date %q", errInvalidArgs, to)
+		}
+		if end.Before(start) {
+			return time.Time{}, time.Time{}, fmt.Errorf("%w: range ends before it starts", errInvalidArgs)
+		}
+		return start, end, nil
+	}
+
+	if len(s) < 2 {
+		return time.Time{}, time.Time{}, fmt.Errorf("%w: invalid range %q", errInvalidArgs, s)
+	}
+	n, err := strconv.Atoi(s[:len(s)-1])
+	if err != nil || n < 1 {
+		return time.Time{}, time.Time{}, fmt.Errorf("%w: invalid range %q", errInvalidArgs, s)
+	}
+
+	switch s[len(s)-1] {
+	case 'd':
+		return today.AddDate(0, 0, -n), today, nil
+	case 'w':
+		return today.AddDate(0, 0, -7*n), today, nil
+	case 'm':
+		return today.AddDate(0, -n, 0), today, nil
+	case 'y':
+		return today.AddDate(-n, 0, 0), today, nil
+	}
+	return time.Time{}, time.Time{}, fmt.Errorf("%w: invalid range %q", errInvalidArgs, s)
+}
+
+type historySummary struct {
+	Symbol        string  `json:"symbol"`
+	From          string  `json:"from"`
+	To            string  `json:"to"`
+	Days          int     `json:"days"`
+	FirstClose    float64 `json:"firstClose"`
+	LastClose     float64 `json:"lastClose"`
+	ChangePercent float64 `json:"changePercent"`
+	High          float64 `json:"high"`
+	Low           float64 `json:"low"`
+	AvgVolume     float64 `json:"avgVolume"`
+}
+
+func summarize(symbol string, bars []bar) historySummary {
+	first, last := bars[0], bars[len(bars)-1]
+	s := historySummary{
+		Symbol:     strings.ToUpper(symbol),
+		From:       first.Date.Format(dateLayout),
+		To:         last.Date.Format(dateLayout),
+		Days:       len(bars),
+		FirstClose: first.Close,
+		LastClose:  last.Close,
+		High:       first.High,
+		Low:        first.Low,
+	}
+	if first.Close != 0 {
+		s.ChangePercent = (last.Close - first.Close) / first.Close * 100
+	}
+
+	var volume float64
+	for _, b := range bars {
+		s.High = max(s.High, b.High)
+		s.Low = min(s.Low, b.Low)
+		volume += b.Volume
+	}
+	s.AvgVolume = volume / float64(len(bars))
+	return s
+}
+
+func (s historySummary) String() string {
+	return fmt.Sprintf("%s %s..%s: close $%.2f -> $%.2f (%+.2f%%), high $%.2f, low $%.2f, avg volume %.0f",
+		s.Symbol, s.From, s.To, s.FirstClose, s.LastClose, s.ChangePercent, s.High, s.Low, s.AvgVolume)
+}
+
+// splitSymbolRange reads "<symbol> [range]" command arguments
+func splitSymbolRange(args string) (string, string) {
+	fields := strings.Fields(args)
+	switch len(fields) {
+	case 0:
+		return "", ""
+	case 1:
+		return fields[0], defaultSpan
+	}
+	return fields[0], fields[1]
+}
+
+func (b *bot) historyCommand(ctx context.Context, m botMessage, args string) (reply, error) {
+	symbol, span := splitSymbolRange(args)
+	if symbol == "" {
+		return reply{}, usage("/history=<symbol> [1w|1m|1y|2024-01-01..2024-03-31]")
+	}
+
+	from, to, err := parseRange(span, time.Now())
+	if err != nil {
+		return reply{}, err
+	}
+
+	bars, err := b.history.History(ctx, symbol, from, to)
+	if err != nil {
+		return reply{}, err
+	}
+
+	summary := summarize(symbol, bars)
+	return reply{Msg: summary.String(), Data: summary}, nil
+}
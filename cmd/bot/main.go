@@ -2,32 +2,52 @@ package main
 
 import (
 	"context"
-	"encoding/csv"
 	"encoding/json"
+	"errors"
+	db "financial-chat-api/db/sqlc"
+	"financial-chat-api/internal/watchlist"
 	"financial-chat-api/util/config"
+	"hash/fnv"
 	"log"
 	"net/http"
-	"strings"
+	"sync"
 	"time"
 
+	"github.com/jackc/pgx/v5/pgxpool"
 	amqp "github.com/rabbitmq/amqp091-go"
 )
 
 const (
-	stockCodeTempl = "STOCK_CODE"
-	stockApi       = "https://stooq.com/q/l/?s=STOCK_CODE&f=sd2t2ohlcv&h&e=csv"
-	receiveQueue   = "financial-sender"
-	sendQueue      = "financial-receiver"
+	receiveQueue = "financial-sender"
+	sendQueue    = "financial-receiver"
+	// jobs each worker may hold in its queue; also used to size the amqp prefetch
+	workerBacklog = 2
 )
 
 func main() {
 	config := config.Load()
-	bot := &bot{
-		rabbitUrl:    config.RabbitUrl,
-		receiveQueue: receiveQueue,
-		sendQueue:    sendQueue,
-		send:         make(chan *botMessage),
+
+	dbpool, err := pgxpool.New(context.Background(), config.DBSource)
+	if err != nil {
+		log.Fatalln("unable to connect to db", err)
+	}
+	defer dbpool.Close()
+
+	err = dbpool.Ping(context.Background())
+	if err != nil {
+		log.Fatalln("cannot ping to db", err)
 	}
+
+	stooq := newStooqProvider(config.BotRequestTimeout)
+	bot := newBot(config, newCachedProvider(stooq, config.QuoteCacheTTL, config.BotRequestTimeout), stooq, dbpool)
+
+	// only the cache counters, the default mux would also serve expvar's command line and memstats
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /debug/vars", cacheVars)
+	go func() {
+		log.Println(http.ListenAndServe(":"+config.BotPort, mux))
+	}()
+
 	go bot.runSender()
 	go bot.runReceiver()
 	forever := make(chan struct{})
@@ -35,15 +55,67 @@ func main() {
 }
 
 type botMessage struct {
-	RoomId string `json:"roomId"`
-	Msg    string `json:"msg"`
+	RoomId     string      `json:"roomId"`
+	Username   string      `json:"username,omitempty"`
+	Msg        string      `json:"msg"`
+	Data       any         `json:"data,omitempty"`
+	Attachment *attachment `json:"attachment,omitempty"`
+}
+
+type job struct {
+	msg      botMessage
+	delivery amqp.Delivery
 }
 
 type bot struct {
-	rabbitUrl    string
-	receiveQueue string
-	sendQueue    string
-	send         chan *botMessage
+	rabbitUrl      string
+	receiveQueue   string
+	sendQueue      string
+	workers        []chan *job
+	requestTimeout time.Duration
+	alertInterval  time.Duration
+	provider       quoteProvider
+	history        historyProvider
+	pool           *pgxpool.Pool
+	store          db.Querier
+	watchlists     watchlistService
+	calendars      calendars
+	symbols        symbolIndex
+	commands       map[string]commandHandler
+}
+
+func newBot(cfg *config.Config, provider quoteProvider, history historyProvider, pool *pgxpool.Pool) *bot {
+	queries := db.New(pool)
+
+	workers := max(cfg.BotWorkers, 1)
+
+	b := &bot{
+		rabbitUrl:      cfg.RabbitUrl,
+		receiveQueue:   receiveQueue,
+		sendQueue:      sendQueue,
+		workers:        make([]chan *job, workers),
+		requestTimeout: cfg.BotRequestTimeout,
+		alertInterval:  cfg.AlertPollInterval,
+		provider:       provider,
+		history:        history,
+		pool:           pool,
+		store:          queries,
+		watchlists:     watchlist.NewService(watchlist.NewRepository(queries)),
+		calendars:      newCalendars(),
+	}
+	err := b.calendars.loadHolidays(cfg.MarketHolidaysFile)
+	if err != nil {
+		log.Println("error loading market holidays, quotes will only account for weekends: ", err)
+	}
+	b.symbols, err = loadSymbols(cfg.SymbolsFile)
+	if err != nil {
+		log.Println("error loading symbols, search and suggestions are disabled: ", err)
+	}
+	b.registerCommands()
+	for i := range b.workers {
+		b.workers[i] = make(chan *job, workerBacklog)
+	}
+	return b
 }
 
 func (b *bot) runReceiver() {
@@ -66,10 +138,18 @@ func (b *bot) runReceiver() {
 	)
 	failOnError(err, "Failed to declare a queue")
 
+	// enough unacked deliveries to keep every worker busy without piling up in memory
+	err = ch.Qos(
+		len(b.workers)*workerBacklog, // prefetch count
+		0,                            // prefetch size
+		false,                        // global
+	)
+	failOnError(err, "Failed to set QoS")
+
 	msgs, err := ch.Consume(
 		b.receiveQueue, // queue
 		"",             // consumer
-		true,           // auto-ack
+		false,          // auto-ack
 		false,          // exclusive
 		false,          // no-local
 		false,          // no-wait
@@ -84,13 +164,22 @@ func (b *bot) runReceiver() {
 		err := json.Unmarshal(d.Body, &botMsg)
 		if err != nil {
 			log.Print("error unmarshaling msg: ", err)
+			d.Nack(false, false)
 			continue
 		}
 
-		b.send <- &botMsg
+		b.workerFor(botMsg.RoomId) <- &job{msg: botMsg, delivery: d}
 	}
 }
 
+// workerFor always picks the same worker for a room, so replies to a room
+// are published in the order its requests were received
+func (b *bot) workerFor(roomId string) chan *job {
+	h := fnv.New32a()
+	h.Write([]byte(roomId))
+	return b.workers[h.Sum32()%uint32(len(b.workers))]
+}
+
 func (b *bot) runSender() {
 	log.Println("sender running...")
 	conn, err := amqp.Dial(b.rabbitUrl)
@@ -110,57 +199,63 @@ func (b *bot) runSender() {
 		nil,         // arguments
 	)
 	failOnError(err, "Failed to declare a queue")
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
 
-	for m := range b.send {
-		stockValue, err := b.getStockValue(m.Msg)
-		if err != nil {
-			log.Println("error calling api: ", err)
-			continue
-		}
+	go b.runAlerts(ch, b.alertInterval)
+	go b.runSchedules(ch)
 
-		body, err := json.Marshal(&botMessage{RoomId: m.RoomId, Msg: stockValue})
-		if err != nil {
-			log.Println("error encoding message for rabbitmq", err)
-			continue
-		}
-		err = ch.PublishWithContext(ctx,
-			"",          // exchange
-			b.sendQueue, // routing key
-			false,       // mandatory
-			false,       // immediate
-			amqp.Publishing{
-				ContentType: "application/json",
-				Body:        []byte(body),
-			})
-		failOnError(err, "Failed to publish a message")
-		log.Printf("Sent to queue: %s\n", body)
+	var wg sync.WaitGroup
+	for i, jobs := range b.workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			log.Printf("worker %d running...", i)
+			for j := range jobs {
+				b.process(ch, j)
+			}
+		}()
 	}
+	wg.Wait()
 }
 
-func (b *bot) getStockValue(stock string) (string, error) {
-	client := http.DefaultClient //not prod-ready
-	resp, err := client.Get(strings.Replace(stockApi, stockCodeTempl, stock, 1))
-	if err != nil {
-		return "", err
-	}
-	defer resp.Body.Close()
+func (b *bot) process(ch *amqp.Channel, j *job) {
+	defer j.delivery.Ack(false)
+
+	ctx, cancel := context.WithTimeout(context.Background(), b.requestTimeout)
+	defer cancel()
 
-	csvReader := csv.NewReader(resp.Body)
-	records, err := csvReader.ReadAll()
+	r, ok, err := b.handle(ctx, j.msg)
+	if !ok {
+		return
+	}
 	if err != nil {
-		return "", nil
+		log.Println("error running command: ", err)
+		// only errors the user can fix are worth an answer in the room
+		if !errors.Is(err, errInvalidArgs) && !errors.Is(err, errNoData) {
+			return
+		}
+		r = reply{Msg: err.Error()}
 	}
 
-	stockValue := records[1][3]
+	b.publish(ctx, ch, &botMessage{RoomId: j.msg.RoomId, Msg: r.Msg, Data: r.Data, Attachment: r.Attachment})
+}
 
-	var sb strings.Builder
-	sb.WriteString(strings.ToUpper(stock))
-	sb.WriteString(" quote is $")
-	sb.WriteString(stockValue)
-	sb.WriteString(" per share")
-	return sb.String(), nil
+func (b *bot) publish(ctx context.Context, ch *amqp.Channel, m *botMessage) {
+	body, err := json.Marshal(m)
+	if err != nil {
+		log.Println("error encoding message for rabbitmq", err)
+		return
+	}
+	err = ch.PublishWithContext(ctx,
+		"",          // exchange
+		b.sendQueue, // routing key
+		false,       // mandatory
+		false,       // immediate
+		amqp.Publishing{
+			ContentType: "application/json",
+			Body:        body,
+		})
+	failOnError(err, "Failed to publish a message")
+	log.Printf("Sent to queue: %s\n", body)
 }
 
 func failOnError(err error, msg string) {
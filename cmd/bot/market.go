@@ -0,0 +1,158 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// stooq reports quote dates and times in Polish time
+var stooqLocation = mustLoadLocation("Europe/Warsaw")
+
+// exchange is the trading calendar of the market behind a symbol suffix
+type exchange struct {
+	name     string
+	loc      *time.Location
+	open     time.Duration
+	close    time.Duration
+	holidays map[string]string
+}
+
+type marketStatus struct {
+	Exchange     string    `json:"exchange"`
+	Open         bool      `json:"open"`
+	LastTrade    time.Time `json:"lastTrade"`
+	DelayMinutes int       `json:"delayMinutes"`
+	Holiday      string    `json:"holiday,omitempty"`
+}
+
+// calendars maps symbol suffixes, like "us" in aapl.us, to their exchange
+type calendars map[string]*exchange
+
+func newCalendars() calendars {
+	return calendars{
+		"us": {
+			name:     "US",
+			loc:      mustLoadLocation("America/New_York"),
+			open:     9*time.Hour + 30*time.Minute,
+			close:    16 * time.Hour,
+			holidays: make(map[string]string),
+		},
+		"uk": {
+			name:     "UK",
+			loc:      mustLoadLocation("Europe/London"),
+			open:     8 * time.Hour,
+			close:    16*time.Hour + 30*time.Minute,
+			holidays: make(map[string]string),
+		},
+	}
+}
+
+func mustLoadLocation(name string) *time.Location {
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		panic(err)
+	}
+	return loc
+}
+
+// loadHolidays reads an exchange,date,name csv with a header row into the calendars
+func (c calendars) loadHolidays(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	records, err := csv.NewReader(f).ReadAll()
+	if err != nil {
+		return err
+	}
+
+	for i, record := range records {
+		if i == 0 || len(record) < 2 {
+			continue
+		}
+		e, ok := c[strings.ToLower(record[0])]
+		if !ok {
+			return fmt.Errorf("line %d: unknown exchange %q", i+1, record[0])
+		}
+		_, err := time.Parse(dateLayout, record[1])
+		if err != nil {
+			return fmt.Errorf("line %d: invalid date %q", i+1, record[1])
+		}
+
+		name := "holiday"
+		if len(record) > 2 && record[2] != "" {
+			name = record[2]
+		}
+		e.holidays[record[1]] = name
+	}
+	return nil
+}
+
+func (c calendars) forSymbol(symbol string) (*exchange, bool) {
+	i := strings.LastIndex(symbol, ".")
+	if i < 0 {
+		return nil, false
+	}
+	e, ok := c[strings.ToLower(symbol[i+1:])]
+	return e, ok
+}
+
+// isOpen reports whether the exchange is trading at t, and the holiday name when it is closed for one
+func (e *exchange) isOpen(t time.Time) (bool, string) {
+	local := t.In(e.loc)
+	if holiday, ok := e.holidays[local.Format(dateLayout)]; ok {
+		return false, holiday
+	}
+	if local.Weekday() == time.Saturday || local.Weekday() == time.Sunday {
+		return false, ""
+	}
+
+	midnight := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, e.loc)
+	sinceMidnight := local.Sub(midnight)
+	return sinceMidnight >= e.open && sinceMidnight < e.close, ""
+}
+
+// status tells whether the market of the quote is open at now and how old the quote is,
+// it is false for symbols without a known exchange
+func (c calendars) status(q quote, now time.Time) (marketStatus, bool) {
+	e, ok := c.forSymbol(q.Symbol)
+	if !ok {
+		return marketStatus{}, false
+	}
+
+	lastTrade, err := time.ParseInLocation(dateLayout+" 15:04:05", q.Date+" "+q.Time, stooqLocation)
+	if err != nil {
+		return marketStatus{}, false
+	}
+
+	open, holiday := e.isOpen(now)
+	s := marketStatus{
+		Exchange:  e.name,
+		Open:      open,
+		LastTrade: lastTrade.In(e.loc),
+		Holiday:   holiday,
+	}
+	if open {
+		s.DelayMinutes = max(int(now.Sub(lastTrade).Minutes()), 0)
+	}
+	return s, true
+}
+
+func (s marketStatus) String() string {
+	if !s.Open {
+		closed := "market closed"
+		if s.Holiday != "" {
+			closed += " for " + s.Holiday
+		}
+		return fmt.Sprintf("%s, last close at %s", closed, s.LastTrade.Format("Mon Jan 2 15:04 MST"))
+	}
+	if s.DelayMinutes > 0 {
+		return fmt.Sprintf("delayed %d min", s.DelayMinutes)
+	}
+	return "live"
+}
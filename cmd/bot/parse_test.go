@@ -0,0 +1,145 @@
+package main
+
+import (
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestParseQuote(t *testing.T) {
+	tests := []struct {
+		record  []string
+		want    quote
+		wantErr error
+	}{
+		{
+			record: []string{"AAPL.US", "2026-10-16", "22:00:09", "247.1", "249.3", "245.9", "248.5", "41234567"},
+			want:   quote{Symbol: "AAPL.US", Date: "2026-10-16", Time: "22:00:09", Open: 247.1, High: 249.3, Low: 245.9, Close: 248.5, Volume: 41234567},
+		},
+		{
+			// currencies have no volume
+			record: []string{"EURUSD", "2026-10-16", "22:59:58", "1.17", "1.18", "1.16", "1.175", ""},
+			want:   quote{Symbol: "EURUSD", Date: "2026-10-16", Time: "22:59:58", Open: 1.17, High: 1.18, Low: 1.16, Close: 1.175},
+		},
+		{
+			record:  []string{"NOPE.US", "N/D", "N/D", "N/D", "N/D", "N/D", "N/D", "N/D"},
+			wantErr: errNoData,
+		},
+		{
+			record:  []string{"AAPL.US", "2026-10-16", "22:00:09", "247.1", "x", "245.9", "248.5", "1"},
+			wantErr: strconv.ErrSyntax,
+		},
+	}
+
+	for _, tt := range tests {
+		got, err := parseQuote(tt.record)
+		if tt.wantErr != nil {
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("parseQuote(%v) error = %v, want %v", tt.record, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("parseQuote(%v) = %+v, %v, want %+v", tt.record, got, err, tt.want)
+		}
+	}
+}
+
+func TestParseRange(t *testing.T) {
+	now := time.Date(2026, 10, 19, 15, 30, 0, 0, time.UTC)
+	today := time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		s        string
+		from, to time.Time
+		wantErr  bool
+	}{
+		{s: "5d", from: time.Date(2026, 10, 14, 0, 0, 0, 0, time.UTC), to: today},
+		{s: "2w", from: time.Date(2026, 10, 5, 0, 0, 0, 0, time.UTC), to: today},
+		{s: "1m", from: time.Date(2026, 9, 19, 0, 0, 0, 0, time.UTC), to: today},
+		{s: "1y", from: time.Date(2025, 10, 19, 0, 0, 0, 0, time.UTC), to: today},
+		{
+			s:    "2024-01-01..2024-03-31",
+			from: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+			to:   time.Date(2024, 3, 31, 0, 0, 0, 0, time.UTC),
+		},
+		{s: "2024-03-31..2024-01-01", wantErr: true},
+		{s: "2024-13-01..2024-03-31", wantErr: true},
+		{s: "2024-01-01..tomorrow", wantErr: true},
+		{s: "0d", wantErr: true},
+		{s: "-1w", wantErr: true},
+		{s: "3q", wantErr: true},
+		{s: "d", wantErr: true},
+		{s: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		from, to, err := parseRange(tt.s, now)
+		if tt.wantErr {
+			if !errors.Is(err, errInvalidArgs) {
+				t.Errorf("parseRange(%q) error = %v, want errInvalidArgs", tt.s, err)
+			}
+			continue
+		}
+		if err != nil || !from.Equal(tt.from) || !to.Equal(tt.to) {
+			t.Errorf("parseRange(%q) = %s, %s, %v, want %s, %s", tt.s, from, to, err, tt.from, tt.to)
+		}
+	}
+}
+
+func TestParseTrade(t *testing.T) {
+	tests := []struct {
+		args     string
+		quantity float64
+		symbol   string
+		wantErr  bool
+	}{
+		{args: "10 AAPL.US", quantity: 10, symbol: "aapl.us"},
+		{args: "0.5 msft.us", quantity: 0.5, symbol: "msft.us"},
+		{args: "  3   tsla.us ", quantity: 3, symbol: "tsla.us"},
+		{args: "0 aapl.us", wantErr: true},
+		{args: "-1 aapl.us", wantErr: true},
+		{args: "inf aapl.us", wantErr: true},
+		{args: "+Inf aapl.us", wantErr: true},
+		{args: "NaN aapl.us", wantErr: true},
+		{args: "ten aapl.us", wantErr: true},
+		{args: "10", wantErr: true},
+		{args: "10 aapl.us msft.us", wantErr: true},
+		{args: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		quantity, symbol, err := parseTrade(sideBuy, tt.args)
+		if tt.wantErr {
+			if !errors.Is(err, errInvalidArgs) {
+				t.Errorf("parseTrade(%q) error = %v, want errInvalidArgs", tt.args, err)
+			}
+			continue
+		}
+		if err != nil || quantity != tt.quantity || symbol != tt.symbol {
+			t.Errorf("parseTrade(%q) = %v, %q, %v, want %v, %q", tt.args, quantity, symbol, err, tt.quantity, tt.symbol)
+		}
+	}
+}
+
+func TestLevenshtein(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{a: "", b: "", want: 0},
+		{a: "aapl", b: "", want: 4},
+		{a: "", b: "msft", want: 4},
+		{a: "aapl", b: "aapl", want: 0},
+		{a: "aapl", b: "appl", want: 1},
+		{a: "kitten", b: "sitting", want: 3},
+		{a: "nvda", b: "nvidia", want: 2},
+	}
+
+	for _, tt := range tests {
+		if got := levenshtein(tt.a, tt.b); got != tt.want {
+			t.Errorf("levenshtein(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
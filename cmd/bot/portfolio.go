@@ -0,0 +1,253 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	db "financial-chat-api/db/sqlc"
+
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	sideBuy  = "buy"
+	sideSell = "sell"
+
+	// quantityEpsilon absorbs the float dust of adding and subtracting fractional
+	// shares, like selling 0.1 three times out of 0.3
+	quantityEpsilon = 1e-9
+)
+
+type tradeResult struct {
+	Trade       db.Trade `json:"trade"`
+	Position    float64  `json:"position"`
+	RealizedPnL float64  `json:"realizedPnl,omitempty"`
+}
+
+type positionValue struct {
+	Symbol        string   `json:"symbol"`
+	Quantity      float64  `json:"quantity"`
+	CostBasis     float64  `json:"costBasis"`
+	AvgCost       float64  `json:"avgCost"`
+	Price         *float64 `json:"price"`
+	MarketValue   *float64 `json:"marketValue"`
+	UnrealizedPnL *float64 `json:"unrealizedPnl"`
+}
+
+type portfolio struct {
+	Username      string          `json:"username"`
+	Positions     []positionValue `json:"positions"`
+	CostBasis     float64         `json:"costBasis"`
+	MarketValue   float64         `json:"marketValue"`
+	UnrealizedPnL float64         `json:"unrealizedPnl"`
+}
+
+// execTx runs fn in a transaction, committing it only when fn succeeds
+func (b *bot) execTx(ctx context.Context, fn func(*db.Queries) error) error {
+	tx, err := b.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	err = fn(db.New(tx))
+	if err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// parseTrade reads "<quantity> <symbol>" trade arguments
+func parseTrade(side, args string) (float64, string, error) {
+	fields := strings.Fields(args)
+	if len(fields) != 2 {
+		return 0, "", usage(fmt.Sprintf("/%s <quantity> <symbol>", side))
+	}
+
+	quantity, err := parseNumber(fields[0])
+	if err != nil || quantity <= 0 {
+		return 0, "", fmt.Errorf("%w: quantity must be a positive number", errInvalidArgs)
+	}
+	return quantity, strings.ToLower(fields[1]), nil
+}
+
+func (b *bot) buyCommand(ctx context.Context, m botMessage, args string) (reply, error) {
+	quantity, symbol, err := parseTrade(sideBuy, args)
+	if err != nil {
+		return reply{}, err
+	}
+
+	q, err := b.provider.Quote(ctx, symbol)
+	if err != nil {
+		return reply{}, err
+	}
+
+	var res tradeResult
+	err = b.execTx(ctx, func(queries *db.Queries) error {
+		trade, err := queries.CreateTrade(ctx, db.CreateTradeParams{
+			Username: m.Username,
+			Symbol:   symbol,
+			Side:     sideBuy,
+			Quantity: quantity,
+			Price:    q.Close,
+		})
+		if err != nil {
+			return err
+		}
+
+		position, err := queries.AddToPosition(ctx, db.AddToPositionParams{
+			Username:  m.Username,
+			Symbol:    symbol,
+			Quantity:  quantity,
+			CostBasis: quantity * q.Close,
+		})
+		if err != nil {
+			return err
+		}
+
+		res = tradeResult{Trade: trade, Position: position.Quantity}
+		return nil
+	})
+	if err != nil {
+		return reply{}, err
+	}
+
+	msg := fmt.Sprintf("%s bought %s %s at $%.2f, position is now %s",
+		m.Username, formatQuantity(quantity), strings.ToUpper(symbol), q.Close, formatQuantity(res.Position))
+	return reply{Msg: msg, Data: res}, nil
+}
+
+func (b *bot) sellCommand(ctx context.Context, m botMessage, args string) (reply, error) {
+	quantity, symbol, err := parseTrade(sideSell, args)
+	if err != nil {
+		return reply{}, err
+	}
+
+	q, err := b.provider.Quote(ctx, symbol)
+	if err != nil {
+		return reply{}, err
+	}
+
+	var res tradeResult
+	err = b.execTx(ctx, func(queries *db.Queries) error {
+		position, err := queries.GetPositionForUpdate(ctx, db.GetPositionForUpdateParams{
+			Username: m.Username,
+			Symbol:   symbol,
+		})
+		if errors.Is(err, pgx.ErrNoRows) {
+			return fmt.Errorf("%w: you have no %s position", errInvalidArgs, strings.ToUpper(symbol))
+		}
+		if err != nil {
+			return err
+		}
+		if quantity > position.Quantity+quantityEpsilon {
+			return fmt.Errorf("%w: you only hold %s %s", errInvalidArgs, formatQuantity(position.Quantity), strings.ToUpper(symbol))
+		}
+
+		trade, err := queries.CreateTrade(ctx, db.CreateTradeParams{
+			Username: m.Username,
+			Symbol:   symbol,
+			Side:     sideSell,
+			Quantity: quantity,
+			Price:    q.Close,
+		})
+		if err != nil {
+			return err
+		}
+
+		// the sold shares take their share of the average cost with them
+		soldCost := position.CostBasis * quantity / position.Quantity
+		res = tradeResult{
+			Trade:       trade,
+			Position:    position.Quantity - quantity,
+			RealizedPnL: quantity*q.Close - soldCost,
+		}
+
+		if res.Position < quantityEpsilon {
+			res.Position = 0
+			return queries.DeletePosition(ctx, db.DeletePositionParams{Username: m.Username, Symbol: symbol})
+		}
+		_, err = queries.UpdatePosition(ctx, db.UpdatePositionParams{
+			Username:  m.Username,
+			Symbol:    symbol,
+			Quantity:  res.Position,
+			CostBasis: position.CostBasis - soldCost,
+		})
+		return err
+	})
+	if err != nil {
+		return reply{}, err
+	}
+
+	msg := fmt.Sprintf("%s sold %s %s at $%.2f, realized P&L %s, position is now %s",
+		m.Username, formatQuantity(quantity), strings.ToUpper(symbol), q.Close, formatPnL(res.RealizedPnL), formatQuantity(res.Position))
+	return reply{Msg: msg, Data: res}, nil
+}
+
+func (b *bot) portfolioCommand(ctx context.Context, m botMessage, args string) (reply, error) {
+	positions, err := b.store.ListPositions(ctx, m.Username)
+	if err != nil {
+		return reply{}, err
+	}
+	if len(positions) == 0 {
+		return reply{Msg: m.Username + " has no open positions"}, nil
+	}
+
+	symbols := make([]string, len(positions))
+	for i, p := range positions {
+		symbols[i] = p.Symbol
+	}
+	found, _ := b.quotes(ctx, symbols)
+	prices := make(map[string]float64, len(found))
+	for _, q := range found {
+		prices[strings.ToLower(q.Symbol)] = q.Close
+	}
+
+	res := portfolio{Username: m.Username, Positions: make([]positionValue, len(positions))}
+	lines := make([]string, len(positions))
+	for i, p := range positions {
+		v := positionValue{
+			Symbol:    strings.ToUpper(p.Symbol),
+			Quantity:  p.Quantity,
+			CostBasis: p.CostBasis,
+			AvgCost:   p.CostBasis / p.Quantity,
+		}
+		res.CostBasis += p.CostBasis
+
+		price, ok := prices[p.Symbol]
+		if !ok {
+			lines[i] = fmt.Sprintf("%s %s @ avg $%.2f, price n/a", v.Symbol, formatQuantity(v.Quantity), v.AvgCost)
+			// value it at cost so the totals stay meaningful
+			res.MarketValue += p.CostBasis
+			res.Positions[i] = v
+			continue
+		}
+
+		value := price * p.Quantity
+		pnl := value - p.CostBasis
+		v.Price, v.MarketValue, v.UnrealizedPnL = &price, &value, &pnl
+		res.MarketValue += value
+		res.UnrealizedPnL += pnl
+		res.Positions[i] = v
+		lines[i] = fmt.Sprintf("%s %s @ avg $%.2f, now $%.2f, P&L %s",
+			v.Symbol, formatQuantity(v.Quantity), v.AvgCost, price, formatPnL(pnl))
+	}
+
+	msg := fmt.Sprintf("%s's portfolio: %s; total value $%.2f, unrealized P&L %s",
+		m.Username, strings.Join(lines, "; "), res.MarketValue, formatPnL(res.UnrealizedPnL))
+	return reply{Msg: msg, Data: res}, nil
+}
+
+func formatQuantity(quantity float64) string {
+	return strconv.FormatFloat(quantity, 'f', -1, 64)
+}
+
+func formatPnL(pnl float64) string {
+	if pnl < 0 {
+		return fmt.Sprintf("-$%.2f", -pnl)
+	}
+	return fmt.Sprintf("+$%.2f", pnl)
+}
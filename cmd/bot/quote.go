@@ -0,0 +1,112 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	stockCodeTempl = "STOCK_CODE"
+	stockApi       = "https://stooq.com/q/l/?s=STOCK_CODE&f=sd2t2ohlcv&h&e=csv"
+	noData         = "N/D"
+)
+
+var errNoData = errors.New("no data for symbol")
+
+// quote is a single row of the stooq latest quote csv
+type quote struct {
+	Symbol string  `json:"symbol"`
+	Date   string  `json:"date"`
+	Time   string  `json:"time"`
+	Open   float64 `json:"open"`
+	High   float64 `json:"high"`
+	Low    float64 `json:"low"`
+	Close  float64 `json:"close"`
+	Volume float64 `json:"volume"`
+}
+
+type quoteProvider interface {
+	Quote(ctx context.Context, symbol string) (quote, error)
+}
+
+type stooqProvider struct {
+	client *http.Client
+}
+
+func newStooqProvider(timeout time.Duration) *stooqProvider {
+	return &stooqProvider{client: &http.Client{Timeout: timeout}}
+}
+
+func (p *stooqProvider) Quote(ctx context.Context, symbol string) (quote, error) {
+	url := strings.Replace(stockApi, stockCodeTempl, symbol, 1)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return quote{}, err
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return quote{}, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return quote{}, fmt.Errorf("stooq responded with status %d", resp.StatusCode)
+	}
+
+	records, err := csv.NewReader(resp.Body).ReadAll()
+	if err != nil {
+		return quote{}, err
+	}
+	if len(records) < 2 || len(records[1]) < 8 {
+		return quote{}, errNoData
+	}
+
+	return parseQuote(records[1])
+}
+
+// parseQuote builds a quote from a Symbol,Date,Time,Open,High,Low,Close,Volume record
+func parseQuote(record []string) (quote, error) {
+	if record[1] == noData {
+		return quote{}, errNoData
+	}
+
+	var values [5]float64
+	for i, field := range record[3:8] {
+		// currencies and indexes have no volume
+		if i == 4 && field == "" {
+			break
+		}
+		v, err := strconv.ParseFloat(field, 64)
+		if err != nil {
+			return quote{}, fmt.Errorf("invalid value %q in quote: %w", field, err)
+		}
+		values[i] = v
+	}
+
+	return quote{
+		Symbol: record[0],
+		Date:   record[1],
+		Time:   record[2],
+		Open:   values[0],
+		High:   values[1],
+		Low:    values[2],
+		Close:  values[3],
+		Volume: values[4],
+	}, nil
+}
+
+func formatQuote(q quote) string {
+	var sb strings.Builder
+	sb.WriteString(strings.ToUpper(q.Symbol))
+	sb.WriteString(" quote is $")
+	sb.WriteString(strconv.FormatFloat(q.Open, 'f', -1, 64))
+	sb.WriteString(" per share")
+	return sb.String()
+}
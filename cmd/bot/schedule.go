@@ -0,0 +1,115 @@
+package main
+
+import (
+	"context"
+	"log"
+	"time"
+
+	db "financial-chat-api/db/sqlc"
+	"financial-chat-api/util/cron"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// runs missed by more than this, like while the bot was down, are skipped
+const scheduleGrace = 10 * time.Minute
+
+type marketSummary struct {
+	ScheduleID int64    `json:"scheduleId"`
+	Quotes     []quote  `json:"quotes"`
+	Missing    []string `json:"missing"`
+}
+
+// runSchedules checks every minute which room schedules are due and posts
+// their market summary into the room
+func (b *bot) runSchedules(ch *amqp.Channel) {
+	log.Println("schedules running...")
+	// wake up right after each minute starts, when the cron specs match
+	time.Sleep(time.Until(time.Now().Truncate(time.Minute).Add(time.Minute)))
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
+
+	for {
+		b.checkSchedules(ch, time.Now())
+		<-ticker.C
+	}
+}
+
+func (b *bot) checkSchedules(ch *amqp.Channel, now time.Time) {
+	schedules, err := b.listSchedules()
+	if err != nil {
+		log.Println("error listing schedules: ", err)
+		return
+	}
+
+	for _, s := range schedules {
+		due, err := isDue(s, now)
+		if err != nil {
+			log.Printf("error checking schedule #%d: %s", s.ID, err)
+			continue
+		}
+		if due.IsZero() {
+			continue
+		}
+
+		b.runSchedule(ch, s, due, now)
+	}
+}
+
+func (b *bot) listSchedules() ([]db.Schedule, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), b.requestTimeout)
+	defer cancel()
+	return b.store.ListSchedules(ctx)
+}
+
+// runSchedule posts a due schedule with its own timeout, a slow one doesn't
+// make the rest of the minute's posts time out
+func (b *bot) runSchedule(ch *amqp.Channel, s db.Schedule, due time.Time, now time.Time) {
+	ctx, cancel := context.WithTimeout(context.Background(), b.requestTimeout)
+	defer cancel()
+
+	if now.Sub(due) <= scheduleGrace {
+		quotes, missing := b.quotes(ctx, s.Symbols)
+		b.publish(ctx, ch, &botMessage{
+			RoomId: uuid.UUID(s.RoomID.Bytes).String(),
+			Msg:    "Market summary: " + formatQuotes(quotes, missing),
+			Data:   marketSummary{ScheduleID: s.ID, Quotes: quotes, Missing: missing},
+		})
+	} else {
+		log.Printf("skipping schedule #%d run missed at %s", s.ID, due)
+	}
+
+	err := b.store.MarkScheduleRun(ctx, db.MarkScheduleRunParams{
+		ID:        s.ID,
+		LastRunAt: pgtype.Timestamptz{Time: now, Valid: true},
+	})
+	if err != nil {
+		log.Printf("error marking schedule #%d run: %s", s.ID, err)
+	}
+}
+
+// isDue returns when the schedule should have last run, or the zero time
+// when it has no pending run at now
+func isDue(s db.Schedule, now time.Time) (time.Time, error) {
+	spec, err := cron.Parse(s.Spec)
+	if err != nil {
+		return time.Time{}, err
+	}
+	loc, err := time.LoadLocation(s.Timezone)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	last := s.CreatedAt.Time
+	if s.LastRunAt.Valid {
+		last = s.LastRunAt.Time
+	}
+
+	next := spec.Next(last.In(loc))
+	if next.IsZero() || next.After(now) {
+		return time.Time{}, nil
+	}
+	return next, nil
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+const (
+	searchLimit     = 10
+	suggestionLimit = 5
+	// edits allowed between a mistyped symbol and a suggestion
+	maxSuggestionDistance = 2
+)
+
+type symbolEntry struct {
+	Symbol string `json:"symbol"`
+	Name   string `json:"name"`
+}
+
+// symbolIndex is the list of known stooq symbols, used to search them by
+// name and to suggest fixes for mistyped ones
+type symbolIndex []symbolEntry
+
+// loadSymbols reads a symbol,name csv with a header row
+func loadSymbols(path string) (symbolIndex, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	records, err := csv.NewReader(f).ReadAll()
+	if err != nil {
+		return nil, err
+	}
+
+	index := make(symbolIndex, 0, len(records))
+	for i, record := range records {
+		if i == 0 {
+			continue
+		}
+		if len(record) < 2 || record[0] == "" {
+			return nil, fmt.Errorf("line %d: expected symbol,name", i+1)
+		}
+		index = append(index, symbolEntry{Symbol: strings.ToLower(record[0]), Name: record[1]})
+	}
+	return index, nil
+}
+
+// baseSymbol strips the market suffix, aapl.us is aapl
+func baseSymbol(symbol string) string {
+	base, _, _ := strings.Cut(symbol, ".")
+	return base
+}
+
+// search finds symbols matching text, exact tickers first, then ticker
+// prefixes and then names containing text
+func (idx symbolIndex) search(text string, limit int) []symbolEntry {
+	text = strings.ToLower(strings.TrimSpace(text))
+	if text == "" {
+		return nil
+	}
+
+	type match struct {
+		entry symbolEntry
+		rank  int
+	}
+	var matches []match
+	for _, e := range idx {
+		switch {
+		case e.Symbol == text || baseSymbol(e.Symbol) == text:
+			matches = append(matches, match{e, 0})
+		case strings.HasPrefix(e.Symbol, text):
+			matches = append(matches, match{e, 1})
+		case strings.Contains(strings.ToLower(e.Name), text):
+			matches = append(matches, match{e, 2})
+		}
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool { return matches[i].rank < matches[j].rank })
+	res := make([]symbolEntry, 0, min(len(matches), limit))
+	for _, m := range matches[:min(len(matches), limit)] {
+		res = append(res, m.entry)
+	}
+	return res
+}
+
+// suggest finds the known symbols closest to a mistyped one, like aapl.us for aapl or apl.us
+func (idx symbolIndex) suggest(symbol string, limit int) []symbolEntry {
+	symbol = strings.ToLower(strings.TrimSpace(symbol))
+	base := baseSymbol(symbol)
+
+	type match struct {
+		entry    symbolEntry
+		distance int
+	}
+	var matches []match
+	for _, e := range idx {
+		if e.Symbol == symbol {
+			continue
+		}
+		d := min(levenshtein(symbol, e.Symbol), levenshtein(base, baseSymbol(e.Symbol)))
+		if d <= maxSuggestionDistance {
+			matches = append(matches, match{e, d})
+		}
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool { return matches[i].distance < matches[j].distance })
+	res := make([]symbolEntry, 0, min(len(matches), limit))
+	for _, m := range matches[:min(len(matches), limit)] {
+		res = append(res, m.entry)
+	}
+	return res
+}
+
+func levenshtein(a, b string) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(b)]
+}
+
+func formatEntries(entries []symbolEntry) string {
+	lines := make([]string, len(entries))
+	for i, e := range entries {
+		lines[i] = fmt.Sprintf("%s (%s)", e.Symbol, e.Name)
+	}
+	return strings.Join(lines, ", ")
+}
+
+func (b *bot) searchCommand(ctx context.Context, m botMessage, args string) (reply, error) {
+	if args == "" {
+		return reply{}, usage("/search <text>")
+	}
+
+	matches := b.symbols.search(args, searchLimit)
+	if len(matches) == 0 {
+		return reply{Msg: fmt.Sprintf("no symbols found for %q", args), Data: matches}, nil
+	}
+	return reply{Msg: "symbols: " + formatEntries(matches), Data: matches}, nil
+}
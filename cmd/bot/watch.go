@@ -0,0 +1,136 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+
+	"financial-chat-api/internal/watchlist"
+)
+
+type watchlistService interface {
+	Get(ctx context.Context, username, name string) (watchlist.Watchlist, error)
+	Add(ctx context.Context, username, name, symbol string) (watchlist.Watchlist, error)
+	Remove(ctx context.Context, username, name, symbol string) (watchlist.Watchlist, error)
+}
+
+type watchlistQuotes struct {
+	Name    string   `json:"name"`
+	Quotes  []quote  `json:"quotes"`
+	Missing []string `json:"missing"`
+}
+
+const (
+	watchUsage = "/watch [name] | /watch add <symbol> [name] | /watch remove <symbol> [name]"
+	// maxConcurrentQuotes bounds the lookups of a single command, so a long list doesn't burst into stooq
+	maxConcurrentQuotes = 4
+)
+
+func (b *bot) watchCommand(ctx context.Context, m botMessage, args string) (reply, error) {
+	fields := strings.Fields(args)
+	if len(fields) > 3 {
+		return reply{}, usage(watchUsage)
+	}
+
+	var action, symbol, name string
+	switch {
+	case len(fields) == 0:
+	case fields[0] == "add" || fields[0] == "remove":
+		if len(fields) < 2 {
+			return reply{}, usage(watchUsage)
+		}
+		action, symbol = fields[0], fields[1]
+		if len(fields) == 3 {
+			name = fields[2]
+		}
+	case len(fields) == 1:
+		name = fields[0]
+	default:
+		return reply{}, usage(watchUsage)
+	}
+
+	var list watchlist.Watchlist
+	var err error
+	switch action {
+	case "add":
+		// only keep symbols stooq knows about
+		if _, err := b.provider.Quote(ctx, symbol); err != nil {
+			return reply{}, err
+		}
+		list, err = b.watchlists.Add(ctx, m.Username, name, symbol)
+	case "remove":
+		list, err = b.watchlists.Remove(ctx, m.Username, name, symbol)
+	default:
+		list, err = b.watchlists.Get(ctx, m.Username, name)
+	}
+	if errors.Is(err, watchlist.ErrInvalidName) || errors.Is(err, watchlist.ErrInvalidSymbol) ||
+		errors.Is(err, watchlist.ErrNotFound) || errors.Is(err, watchlist.ErrFull) {
+		return reply{}, fmt.Errorf("%w: %s", errInvalidArgs, err)
+	}
+	if err != nil {
+		return reply{}, err
+	}
+
+	if len(list.Symbols) == 0 {
+		return reply{Msg: fmt.Sprintf("%s's watchlist %s is empty", m.Username, list.Name)}, nil
+	}
+
+	quotes := b.watchlistQuotes(ctx, list)
+	return reply{Msg: quotes.String(m.Username), Data: quotes}, nil
+}
+
+func (b *bot) watchlistQuotes(ctx context.Context, list watchlist.Watchlist) watchlistQuotes {
+	quotes, missing := b.quotes(ctx, list.Symbols)
+	return watchlistQuotes{Name: list.Name, Quotes: quotes, Missing: missing}
+}
+
+// quotes looks up the quotes of every symbol, up to maxConcurrentQuotes at a
+// time, the symbols that failed are returned apart
+func (b *bot) quotes(ctx context.Context, symbols []string) ([]quote, []string) {
+	quotes := make([]quote, len(symbols))
+	errs := make([]error, len(symbols))
+
+	sem := make(chan struct{}, maxConcurrentQuotes)
+	var wg sync.WaitGroup
+	for i, symbol := range symbols {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			quotes[i], errs[i] = b.provider.Quote(ctx, symbol)
+		}()
+	}
+	wg.Wait()
+
+	found, missing := []quote{}, []string{}
+	for i, symbol := range symbols {
+		if errs[i] != nil {
+			log.Printf("error getting quote for %s: %s", symbol, errs[i])
+			missing = append(missing, symbol)
+			continue
+		}
+		found = append(found, quotes[i])
+	}
+	return found, missing
+}
+
+func (w watchlistQuotes) String(username string) string {
+	return fmt.Sprintf("%s's watchlist %s: %s", username, w.Name, formatQuotes(w.Quotes, w.Missing))
+}
+
+func formatQuotes(quotes []quote, missing []string) string {
+	entries := make([]string, 0, len(quotes)+len(missing))
+	for _, q := range quotes {
+		entries = append(entries, fmt.Sprintf("%s $%.2f", strings.ToUpper(q.Symbol), q.Close))
+	}
+	for _, symbol := range missing {
+		entries = append(entries, strings.ToUpper(symbol)+" n/a")
+	}
+	return strings.Join(entries, ", ")
+}
@@ -2,9 +2,12 @@ package main
 
 import (
 	"context"
+	"expvar"
 	db "financial-chat-api/db/sqlc"
 	"financial-chat-api/internal/chat"
+	"financial-chat-api/internal/schedule"
 	"financial-chat-api/internal/user"
+	"financial-chat-api/internal/watchlist"
 	"financial-chat-api/util/auth"
 	"financial-chat-api/util/config"
 	"financial-chat-api/util/password"
@@ -44,9 +47,9 @@ func main() {
 		log.Fatalln("error creating tokenMaker", err)
 	}
 
-	userRepo := user.NewRepository(db)
-	userServ := user.NewService(userRepo, password.Hash, password.Check, tokenMaker)
-	userHandler := user.NewHandler(userServ)
+	watchlistRepo := watchlist.NewRepository(db)
+	watchlistServ := watchlist.NewService(watchlistRepo)
+	watchlistHandler := watchlist.NewHandler(watchlistServ)
 
 	conn, err := amqp.Dial(config.RabbitUrl)
 	failOnError(err, "Failed to connect to RabbitMQ")
@@ -68,10 +71,21 @@ func main() {
 
 	bot := chat.NewBot(ch, config.RabbitUrl, sendQueue, receiveQueue)
 	go bot.Run()
-	hub := chat.NewHub(bot)
-	go hub.Run()
+	// revoked tokens are kept by the user repository, checked on requests and on websocket reauth
+	userRepo := user.NewRepository(db)
+	verifier := auth.NewVerifier(tokenMaker, userRepo)
+
+	hub := chat.NewHub(bot, config, chat.NewRepository(db, dbpool), verifier)
 	chatHandler := chat.NewHandler(hub)
 
+	userServ := user.NewService(userRepo, password.Hash, password.Check, tokenMaker, hub,
+		config.AccessTokenDuration, config.RefreshTokenDuration)
+	userHandler := user.NewHandler(userServ)
+
+	scheduleRepo := schedule.NewRepository(db)
+	scheduleServ := schedule.NewService(scheduleRepo, hub)
+	scheduleHandler := schedule.NewHandler(scheduleServ)
+
 	server := webh.NewServer(
 		config.ServerPort,
 		webh.WithHeartbeat("/ping"),
@@ -83,11 +97,43 @@ func main() {
 
 	server.Post("/users", webh.Unwrap(userHandler.CreateUser))
 	server.Post("/login", webh.Unwrap(userHandler.Login))
+	server.Post("/tokens/refresh", webh.Unwrap(userHandler.Refresh))
+	// websockets authenticate on their own, browsers can't send the Authorization header
+	server.Get("/ws", webh.Unwrap(chatHandler.HandleJoinRoom))
 
 	server.Route("/", func(r chi.Router) {
-		r.Use(auth.Middleware(tokenMaker))
+		r.Use(auth.Middleware(verifier))
+		r.Post("/logout", webh.Unwrap(userHandler.Logout))
+		r.With(auth.AdminOnly(config.AdminUsers)).
+			Delete("/admin/users/{username}/sessions", webh.Unwrap(userHandler.RevokeSessions))
+		// rooms_active and rooms_suspended, along with the runtime stats and the command line
+		r.With(auth.AdminOnly(config.AdminUsers)).
+			Handle("/debug/vars", expvar.Handler())
 		r.Post("/rooms", webh.Unwrap(chatHandler.HandleCreateRoom))
-		r.Get("/ws", webh.Unwrap(chatHandler.HandleJoinRoom))
+		r.Post("/ws-ticket", webh.Unwrap(chatHandler.HandleCreateTicket))
+		r.Get("/rooms/{id}", webh.Unwrap(chatHandler.HandleGetRoom))
+		r.Patch("/rooms/{id}", webh.Unwrap(chatHandler.HandleUpdateRoom))
+		r.Delete("/rooms/{id}", webh.Unwrap(chatHandler.HandleDeleteRoom))
+		r.Post("/rooms/{id}/archive", webh.Unwrap(chatHandler.HandleArchiveRoom))
+		r.Post("/rooms/{id}/invites", webh.Unwrap(chatHandler.HandleInvite))
+		r.Get("/invites", webh.Unwrap(chatHandler.HandleListInvites))
+		r.Post("/invites/{inviteId}/accept", webh.Unwrap(chatHandler.HandleAcceptInvite))
+		r.Get("/dm", webh.Unwrap(chatHandler.HandleListDMs))
+		r.Post("/dm/{username}", webh.Unwrap(chatHandler.HandleOpenDM))
+		r.Post("/rooms/{id}/kicks", webh.Unwrap(chatHandler.HandleKick))
+		r.Post("/rooms/{id}/mutes", webh.Unwrap(chatHandler.HandleMute))
+		r.Delete("/rooms/{id}/mutes/{username}", webh.Unwrap(chatHandler.HandleUnmute))
+		r.Post("/rooms/{id}/bans", webh.Unwrap(chatHandler.HandleBan))
+		r.Delete("/rooms/{id}/bans/{username}", webh.Unwrap(chatHandler.HandleUnban))
+		r.Put("/rooms/{id}/moderators/{username}", webh.Unwrap(chatHandler.HandleAddModerator))
+		r.Delete("/rooms/{id}/moderators/{username}", webh.Unwrap(chatHandler.HandleRemoveModerator))
+		r.Get("/rooms/{id}/schedules", webh.Unwrap(scheduleHandler.List))
+		r.Post("/rooms/{id}/schedules", webh.Unwrap(scheduleHandler.Create))
+		r.Delete("/rooms/{id}/schedules/{scheduleId}", webh.Unwrap(scheduleHandler.Delete))
+		r.Get("/watchlists", webh.Unwrap(watchlistHandler.List))
+		r.Get("/watchlists/{name}", webh.Unwrap(watchlistHandler.Get))
+		r.Put("/watchlists/{name}/symbols/{symbol}", webh.Unwrap(watchlistHandler.AddSymbol))
+		r.Delete("/watchlists/{name}/symbols/{symbol}", webh.Unwrap(watchlistHandler.RemoveSymbol))
 	})
 
 	server.Start()
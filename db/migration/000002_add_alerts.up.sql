@@ -0,0 +1,12 @@
+CREATE TABLE "alerts" (
+  "id" bigserial PRIMARY KEY,
+  "room_id" uuid NOT NULL,
+  "username" varchar NOT NULL REFERENCES "users" ("username"),
+  "symbol" varchar NOT NULL,
+  "operator" varchar NOT NULL,
+  "threshold" double precision NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  "fired_at" timestamptz
+);
+
+CREATE INDEX ON "alerts" ("room_id");
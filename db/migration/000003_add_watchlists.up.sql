@@ -0,0 +1,7 @@
+CREATE TABLE "watchlist_items" (
+  "username" varchar NOT NULL REFERENCES "users" ("username"),
+  "name" varchar NOT NULL,
+  "symbol" varchar NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  PRIMARY KEY ("username", "name", "symbol")
+);
@@ -0,0 +1,12 @@
+CREATE TABLE "schedules" (
+  "id" bigserial PRIMARY KEY,
+  "room_id" uuid NOT NULL,
+  "owner" varchar NOT NULL REFERENCES "users" ("username"),
+  "spec" varchar NOT NULL,
+  "timezone" varchar NOT NULL,
+  "symbols" varchar[] NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  "last_run_at" timestamptz
+);
+
+CREATE INDEX ON "schedules" ("room_id");
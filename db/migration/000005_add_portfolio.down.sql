@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS "positions";
+DROP TABLE IF EXISTS "trades";
\ No newline at end of file
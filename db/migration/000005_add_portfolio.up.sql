@@ -0,0 +1,20 @@
+CREATE TABLE "trades" (
+  "id" bigserial PRIMARY KEY,
+  "username" varchar NOT NULL REFERENCES "users" ("username"),
+  "symbol" varchar NOT NULL,
+  "side" varchar NOT NULL,
+  "quantity" double precision NOT NULL,
+  "price" double precision NOT NULL,
+  "executed_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE INDEX ON "trades" ("username");
+
+CREATE TABLE "positions" (
+  "username" varchar NOT NULL REFERENCES "users" ("username"),
+  "symbol" varchar NOT NULL,
+  "quantity" double precision NOT NULL,
+  "cost_basis" double precision NOT NULL,
+  "updated_at" timestamptz NOT NULL DEFAULT (now()),
+  PRIMARY KEY ("username", "symbol")
+);
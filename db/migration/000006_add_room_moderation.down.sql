@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS "room_sanctions";
+DROP TABLE IF EXISTS "room_members";
\ No newline at end of file
@@ -0,0 +1,20 @@
+CREATE TABLE "room_members" (
+  "room_id" uuid NOT NULL,
+  "username" varchar NOT NULL REFERENCES "users" ("username"),
+  "role" varchar NOT NULL DEFAULT 'member',
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  PRIMARY KEY ("room_id", "username")
+);
+
+CREATE TABLE "room_sanctions" (
+  "id" bigserial PRIMARY KEY,
+  "room_id" uuid NOT NULL,
+  "username" varchar NOT NULL REFERENCES "users" ("username"),
+  "kind" varchar NOT NULL,
+  "reason" varchar NOT NULL DEFAULT '',
+  "created_by" varchar NOT NULL REFERENCES "users" ("username"),
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  "expires_at" timestamptz
+);
+
+CREATE INDEX ON "room_sanctions" ("room_id", "username");
@@ -0,0 +1,10 @@
+CREATE TABLE "room_invites" (
+  "id" bigserial PRIMARY KEY,
+  "room_id" uuid NOT NULL,
+  "username" varchar NOT NULL REFERENCES "users" ("username"),
+  "invited_by" varchar NOT NULL REFERENCES "users" ("username"),
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  UNIQUE ("room_id", "username")
+);
+
+CREATE INDEX ON "room_invites" ("username");
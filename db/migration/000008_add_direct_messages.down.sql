@@ -0,0 +1 @@
+DROP TABLE IF EXISTS "direct_messages";
\ No newline at end of file
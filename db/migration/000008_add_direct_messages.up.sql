@@ -0,0 +1,9 @@
+CREATE TABLE "direct_messages" (
+  "room_id" uuid PRIMARY KEY,
+  "user_a" varchar NOT NULL REFERENCES "users" ("username"),
+  "user_b" varchar NOT NULL REFERENCES "users" ("username"),
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  UNIQUE ("user_a", "user_b")
+);
+
+CREATE INDEX ON "direct_messages" ("user_b");
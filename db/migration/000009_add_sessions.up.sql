@@ -0,0 +1,15 @@
+-- one row per refresh token, rotating a token adds a row to the same family
+CREATE TABLE "sessions" (
+  "id" uuid PRIMARY KEY,
+  "family_id" uuid NOT NULL,
+  "username" varchar NOT NULL REFERENCES "users" ("username"),
+  "token_hash" varchar NOT NULL UNIQUE,
+  "expires_at" timestamptz NOT NULL,
+  "rotated_at" timestamptz,
+  "revoked_at" timestamptz,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE INDEX ON "sessions" ("family_id");
+
+CREATE INDEX ON "sessions" ("username");
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS "revoked_tokens";
+ALTER TABLE "sessions" DROP COLUMN IF EXISTS "access_token_expires_at";
+ALTER TABLE "sessions" DROP COLUMN IF EXISTS "access_token_id";
\ No newline at end of file
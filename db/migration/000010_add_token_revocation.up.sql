@@ -0,0 +1,13 @@
+ALTER TABLE "sessions" ADD COLUMN "access_token_id" uuid;
+
+ALTER TABLE "sessions" ADD COLUMN "access_token_expires_at" timestamptz;
+
+CREATE INDEX ON "sessions" ("access_token_id");
+
+-- access tokens revoked before they expire, rows are useless once expires_at passes
+CREATE TABLE "revoked_tokens" (
+  "id" uuid PRIMARY KEY,
+  "username" varchar NOT NULL REFERENCES "users" ("username"),
+  "expires_at" timestamptz NOT NULL,
+  "revoked_at" timestamptz NOT NULL DEFAULT (now())
+);
@@ -0,0 +1,29 @@
+-- name: CreateAlert :one
+INSERT INTO alerts (
+  room_id, username, symbol, operator, threshold
+) VALUES (
+  $1, $2, $3, $4, $5
+) RETURNING *;
+
+-- name: ListRoomAlerts :many
+SELECT * FROM alerts
+WHERE room_id = $1 AND fired_at IS NULL
+ORDER BY id;
+
+-- name: ListActiveAlerts :many
+SELECT * FROM alerts
+WHERE fired_at IS NULL
+ORDER BY id;
+
+-- name: MarkAlertFired :exec
+UPDATE alerts
+SET fired_at = now()
+WHERE id = $1;
+
+-- name: DeleteAlert :execrows
+DELETE FROM alerts
+WHERE id = $1 AND room_id = $2 AND username = $3 AND fired_at IS NULL;
+
+-- name: DeleteRoomAlerts :exec
+DELETE FROM alerts
+WHERE room_id = $1;
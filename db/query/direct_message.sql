@@ -0,0 +1,11 @@
+-- name: AddDirectMessage :exec
+INSERT INTO direct_messages (
+  room_id, user_a, user_b
+) VALUES (
+  $1, $2, $3
+) ON CONFLICT (room_id) DO NOTHING;
+
+-- name: ListUserDirectMessages :many
+SELECT * FROM direct_messages
+WHERE user_a = sqlc.arg(username) OR user_b = sqlc.arg(username)
+ORDER BY created_at DESC;
@@ -0,0 +1,37 @@
+-- name: CreateTrade :one
+INSERT INTO trades (
+  username, symbol, side, quantity, price
+) VALUES (
+  $1, $2, $3, $4, $5
+) RETURNING *;
+
+-- name: AddToPosition :one
+INSERT INTO positions (
+  username, symbol, quantity, cost_basis
+) VALUES (
+  $1, $2, $3, $4
+) ON CONFLICT (username, symbol) DO UPDATE
+SET quantity = positions.quantity + EXCLUDED.quantity,
+  cost_basis = positions.cost_basis + EXCLUDED.cost_basis,
+  updated_at = now()
+RETURNING *;
+
+-- name: GetPositionForUpdate :one
+SELECT * FROM positions
+WHERE username = $1 AND symbol = $2 LIMIT 1
+FOR UPDATE;
+
+-- name: UpdatePosition :one
+UPDATE positions
+SET quantity = $3, cost_basis = $4, updated_at = now()
+WHERE username = $1 AND symbol = $2
+RETURNING *;
+
+-- name: DeletePosition :exec
+DELETE FROM positions
+WHERE username = $1 AND symbol = $2;
+
+-- name: ListPositions :many
+SELECT * FROM positions
+WHERE username = $1
+ORDER BY symbol;
@@ -0,0 +1,29 @@
+-- name: IsTokenRevoked :one
+SELECT EXISTS (
+  SELECT 1 FROM revoked_tokens WHERE id = $1
+);
+
+-- name: RevokeFamilyAccessTokens :many
+INSERT INTO revoked_tokens (
+  id, username, expires_at
+)
+SELECT access_token_id, username, access_token_expires_at FROM sessions
+WHERE family_id = $1 AND access_token_id IS NOT NULL AND access_token_expires_at > now()
+ON CONFLICT (id) DO NOTHING
+RETURNING id;
+
+-- name: RevokeToken :exec
+INSERT INTO revoked_tokens (
+  id, username, expires_at
+) VALUES (
+  $1, $2, $3
+) ON CONFLICT (id) DO NOTHING;
+
+-- name: RevokeUserAccessTokens :many
+INSERT INTO revoked_tokens (
+  id, username, expires_at
+)
+SELECT access_token_id, username, access_token_expires_at FROM sessions
+WHERE username = $1 AND access_token_id IS NOT NULL AND access_token_expires_at > now()
+ON CONFLICT (id) DO NOTHING
+RETURNING id;
@@ -0,0 +1,71 @@
+-- name: UpsertRoomMember :one
+INSERT INTO room_members (
+  room_id, username, role
+) VALUES (
+  $1, $2, $3
+) ON CONFLICT (room_id, username) DO UPDATE
+SET role = EXCLUDED.role
+RETURNING *;
+
+-- name: GetRoomMember :one
+SELECT * FROM room_members
+WHERE room_id = $1 AND username = $2 LIMIT 1;
+
+-- name: CreateRoomSanction :one
+INSERT INTO room_sanctions (
+  room_id, username, kind, reason, created_by, expires_at
+) VALUES (
+  $1, $2, $3, $4, $5, $6
+) RETURNING *;
+
+-- name: GetActiveRoomSanction :one
+SELECT * FROM room_sanctions
+WHERE room_id = $1 AND username = $2 AND kind = $3
+  AND (expires_at IS NULL OR expires_at > now())
+ORDER BY expires_at DESC NULLS FIRST
+LIMIT 1;
+
+-- name: DeleteRoomSanctions :execrows
+DELETE FROM room_sanctions
+WHERE room_id = $1 AND username = $2 AND kind = $3;
+
+-- name: AddRoomMember :exec
+INSERT INTO room_members (
+  room_id, username, role
+) VALUES (
+  $1, $2, $3
+) ON CONFLICT (room_id, username) DO NOTHING;
+
+-- name: CreateRoomInvite :one
+INSERT INTO room_invites (
+  room_id, username, invited_by
+) VALUES (
+  $1, $2, $3
+) ON CONFLICT (room_id, username) DO UPDATE
+SET invited_by = EXCLUDED.invited_by
+RETURNING *;
+
+-- name: GetRoomInvite :one
+SELECT * FROM room_invites
+WHERE id = $1 LIMIT 1;
+
+-- name: ListUserRoomInvites :many
+SELECT * FROM room_invites
+WHERE username = $1
+ORDER BY id;
+
+-- name: DeleteRoomInvite :exec
+DELETE FROM room_invites
+WHERE id = $1;
+
+-- name: DeleteRoomMembers :exec
+DELETE FROM room_members
+WHERE room_id = $1;
+
+-- name: DeleteRoomInvites :exec
+DELETE FROM room_invites
+WHERE room_id = $1;
+
+-- name: DeleteAllRoomSanctions :exec
+DELETE FROM room_sanctions
+WHERE room_id = $1;
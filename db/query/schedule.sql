@@ -0,0 +1,28 @@
+-- name: CreateSchedule :one
+INSERT INTO schedules (
+  room_id, owner, spec, timezone, symbols
+) VALUES (
+  $1, $2, $3, $4, $5
+) RETURNING *;
+
+-- name: ListSchedules :many
+SELECT * FROM schedules
+ORDER BY id;
+
+-- name: ListRoomSchedules :many
+SELECT * FROM schedules
+WHERE room_id = $1
+ORDER BY id;
+
+-- name: MarkScheduleRun :exec
+UPDATE schedules
+SET last_run_at = $2
+WHERE id = $1;
+
+-- name: DeleteSchedule :execrows
+DELETE FROM schedules
+WHERE id = $1 AND room_id = $2;
+
+-- name: DeleteRoomSchedules :exec
+DELETE FROM schedules
+WHERE room_id = $1;
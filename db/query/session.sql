@@ -0,0 +1,29 @@
+-- name: CreateSession :one
+INSERT INTO sessions (
+  id, family_id, username, token_hash, expires_at, access_token_id, access_token_expires_at
+) VALUES (
+  $1, $2, $3, $4, $5, $6, $7
+) RETURNING *;
+
+-- name: GetSessionByAccessToken :one
+SELECT * FROM sessions
+WHERE access_token_id = $1 LIMIT 1;
+
+-- name: GetSessionByTokenHash :one
+SELECT * FROM sessions
+WHERE token_hash = $1 LIMIT 1;
+
+-- name: RevokeSessionFamily :exec
+UPDATE sessions
+SET revoked_at = now()
+WHERE family_id = $1 AND revoked_at IS NULL;
+
+-- name: RevokeUserSessions :exec
+UPDATE sessions
+SET revoked_at = now()
+WHERE username = $1 AND revoked_at IS NULL;
+
+-- name: RotateSession :execrows
+UPDATE sessions
+SET rotated_at = now()
+WHERE id = $1 AND rotated_at IS NULL AND revoked_at IS NULL;
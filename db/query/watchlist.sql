@@ -0,0 +1,20 @@
+-- name: AddWatchlistSymbol :exec
+INSERT INTO watchlist_items (
+  username, name, symbol
+) VALUES (
+  $1, $2, $3
+) ON CONFLICT DO NOTHING;
+
+-- name: RemoveWatchlistSymbol :execrows
+DELETE FROM watchlist_items
+WHERE username = $1 AND name = $2 AND symbol = $3;
+
+-- name: ListWatchlistItems :many
+SELECT * FROM watchlist_items
+WHERE username = $1 AND name = $2
+ORDER BY symbol;
+
+-- name: ListUserWatchlistItems :many
+SELECT * FROM watchlist_items
+WHERE username = $1
+ORDER BY name, symbol;
@@ -8,8 +8,108 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+type Alert struct {
+	ID        int64              `json:"id"`
+	RoomID    pgtype.UUID        `json:"roomId"`
+	Username  string             `json:"username"`
+	Symbol    string             `json:"symbol"`
+	Operator  string             `json:"operator"`
+	Threshold float64            `json:"threshold"`
+	CreatedAt pgtype.Timestamptz `json:"createdAt"`
+	FiredAt   pgtype.Timestamptz `json:"firedAt"`
+}
+
+type DirectMessage struct {
+	RoomID    pgtype.UUID        `json:"roomId"`
+	UserA     string             `json:"userA"`
+	UserB     string             `json:"userB"`
+	CreatedAt pgtype.Timestamptz `json:"createdAt"`
+}
+
+type Position struct {
+	Username  string             `json:"username"`
+	Symbol    string             `json:"symbol"`
+	Quantity  float64            `json:"quantity"`
+	CostBasis float64            `json:"costBasis"`
+	UpdatedAt pgtype.Timestamptz `json:"updatedAt"`
+}
+
+type RevokedToken struct {
+	ID        pgtype.UUID        `json:"id"`
+	Username  string             `json:"username"`
+	ExpiresAt pgtype.Timestamptz `json:"expiresAt"`
+	RevokedAt pgtype.Timestamptz `json:"revokedAt"`
+}
+
+type RoomInvite struct {
+	ID        int64              `json:"id"`
+	RoomID    pgtype.UUID        `json:"roomId"`
+	Username  string             `json:"username"`
+	InvitedBy string             `json:"invitedBy"`
+	CreatedAt pgtype.Timestamptz `json:"createdAt"`
+}
+
+type RoomMember struct {
+	RoomID    pgtype.UUID        `json:"roomId"`
+	Username  string             `json:"username"`
+	Role      string             `json:"role"`
+	CreatedAt pgtype.Timestamptz `json:"createdAt"`
+}
+
+type RoomSanction struct {
+	ID        int64              `json:"id"`
+	RoomID    pgtype.UUID        `json:"roomId"`
+	Username  string             `json:"username"`
+	Kind      string             `json:"kind"`
+	Reason    string             `json:"reason"`
+	CreatedBy string             `json:"createdBy"`
+	CreatedAt pgtype.Timestamptz `json:"createdAt"`
+	ExpiresAt pgtype.Timestamptz `json:"expiresAt"`
+}
+
+type Schedule struct {
+	ID        int64              `json:"id"`
+	RoomID    pgtype.UUID        `json:"roomId"`
+	Owner     string             `json:"owner"`
+	Spec      string             `json:"spec"`
+	Timezone  string             `json:"timezone"`
+	Symbols   []string           `json:"symbols"`
+	CreatedAt pgtype.Timestamptz `json:"createdAt"`
+	LastRunAt pgtype.Timestamptz `json:"lastRunAt"`
+}
+
+type Session struct {
+	ID                   pgtype.UUID        `json:"id"`
+	FamilyID             pgtype.UUID        `json:"familyId"`
+	Username             string             `json:"username"`
+	TokenHash            string             `json:"tokenHash"`
+	ExpiresAt            pgtype.Timestamptz `json:"expiresAt"`
+	RotatedAt            pgtype.Timestamptz `json:"rotatedAt"`
+	RevokedAt            pgtype.Timestamptz `json:"revokedAt"`
+	CreatedAt            pgtype.Timestamptz `json:"createdAt"`
+	AccessTokenID        pgtype.UUID        `json:"accessTokenId"`
+	AccessTokenExpiresAt pgtype.Timestamptz `json:"accessTokenExpiresAt"`
+}
+
+type Trade struct {
+	ID         int64              `json:"id"`
+	Username   string             `json:"username"`
+	Symbol     string             `json:"symbol"`
+	Side       string             `json:"side"`
+	Quantity   float64            `json:"quantity"`
+	Price      float64            `json:"price"`
+	ExecutedAt pgtype.Timestamptz `json:"executedAt"`
+}
+
 type User struct {
 	Username       string             `json:"username"`
 	HashedPassword string             `json:"hashedPassword"`
 	CreatedAt      pgtype.Timestamptz `json:"createdAt"`
 }
+
+type WatchlistItem struct {
+	Username  string             `json:"username"`
+	Name      string             `json:"name"`
+	Symbol    string             `json:"symbol"`
+	CreatedAt pgtype.Timestamptz `json:"createdAt"`
+}
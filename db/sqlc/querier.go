@@ -6,11 +6,60 @@ package db
 
 import (
 	"context"
+
+	"github.com/jackc/pgx/v5/pgtype"
 )
 
 type Querier interface {
+	AddDirectMessage(ctx context.Context, arg AddDirectMessageParams) error
+	AddRoomMember(ctx context.Context, arg AddRoomMemberParams) error
+	AddToPosition(ctx context.Context, arg AddToPositionParams) (Position, error)
+	AddWatchlistSymbol(ctx context.Context, arg AddWatchlistSymbolParams) error
+	CreateAlert(ctx context.Context, arg CreateAlertParams) (Alert, error)
+	CreateRoomInvite(ctx context.Context, arg CreateRoomInviteParams) (RoomInvite, error)
+	CreateRoomSanction(ctx context.Context, arg CreateRoomSanctionParams) (RoomSanction, error)
+	CreateSchedule(ctx context.Context, arg CreateScheduleParams) (Schedule, error)
+	CreateSession(ctx context.Context, arg CreateSessionParams) (Session, error)
+	CreateTrade(ctx context.Context, arg CreateTradeParams) (Trade, error)
 	CreateUser(ctx context.Context, arg CreateUserParams) (User, error)
+	DeleteAlert(ctx context.Context, arg DeleteAlertParams) (int64, error)
+	DeleteAllRoomSanctions(ctx context.Context, roomID pgtype.UUID) error
+	DeletePosition(ctx context.Context, arg DeletePositionParams) error
+	DeleteRoomAlerts(ctx context.Context, roomID pgtype.UUID) error
+	DeleteRoomInvite(ctx context.Context, id int64) error
+	DeleteRoomInvites(ctx context.Context, roomID pgtype.UUID) error
+	DeleteRoomMembers(ctx context.Context, roomID pgtype.UUID) error
+	DeleteRoomSanctions(ctx context.Context, arg DeleteRoomSanctionsParams) (int64, error)
+	DeleteRoomSchedules(ctx context.Context, roomID pgtype.UUID) error
+	DeleteSchedule(ctx context.Context, arg DeleteScheduleParams) (int64, error)
+	GetActiveRoomSanction(ctx context.Context, arg GetActiveRoomSanctionParams) (RoomSanction, error)
+	GetPositionForUpdate(ctx context.Context, arg GetPositionForUpdateParams) (Position, error)
+	GetRoomInvite(ctx context.Context, id int64) (RoomInvite, error)
+	GetRoomMember(ctx context.Context, arg GetRoomMemberParams) (RoomMember, error)
+	GetSessionByAccessToken(ctx context.Context, accessTokenID pgtype.UUID) (Session, error)
+	GetSessionByTokenHash(ctx context.Context, tokenHash string) (Session, error)
 	GetUser(ctx context.Context, username string) (User, error)
+	IsTokenRevoked(ctx context.Context, id pgtype.UUID) (bool, error)
+	ListActiveAlerts(ctx context.Context) ([]Alert, error)
+	ListPositions(ctx context.Context, username string) ([]Position, error)
+	ListRoomAlerts(ctx context.Context, roomID pgtype.UUID) ([]Alert, error)
+	ListRoomSchedules(ctx context.Context, roomID pgtype.UUID) ([]Schedule, error)
+	ListSchedules(ctx context.Context) ([]Schedule, error)
+	ListUserDirectMessages(ctx context.Context, username string) ([]DirectMessage, error)
+	ListUserRoomInvites(ctx context.Context, username string) ([]RoomInvite, error)
+	ListUserWatchlistItems(ctx context.Context, username string) ([]WatchlistItem, error)
+	ListWatchlistItems(ctx context.Context, arg ListWatchlistItemsParams) ([]WatchlistItem, error)
+	MarkAlertFired(ctx context.Context, id int64) error
+	MarkScheduleRun(ctx context.Context, arg MarkScheduleRunParams) error
+	RemoveWatchlistSymbol(ctx context.Context, arg RemoveWatchlistSymbolParams) (int64, error)
+	RevokeFamilyAccessTokens(ctx context.Context, familyID pgtype.UUID) ([]pgtype.UUID, error)
+	RevokeSessionFamily(ctx context.Context, familyID pgtype.UUID) error
+	RevokeToken(ctx context.Context, arg RevokeTokenParams) error
+	RevokeUserAccessTokens(ctx context.Context, username string) ([]pgtype.UUID, error)
+	RevokeUserSessions(ctx context.Context, username string) error
+	RotateSession(ctx context.Context, id pgtype.UUID) (int64, error)
+	UpdatePosition(ctx context.Context, arg UpdatePositionParams) (Position, error)
+	UpsertRoomMember(ctx context.Context, arg UpsertRoomMemberParams) (RoomMember, error)
 }
 
 var _ Querier = (*Queries)(nil)
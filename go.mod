@@ -28,6 +28,6 @@ require (
 	github.com/joho/godotenv v1.5.1
 	github.com/o1egl/paseto v1.0.0
 	github.com/tomiok/webh v0.1.3
-	golang.org/x/sync v0.1.0 // indirect
-	golang.org/x/text v0.15.0 // indirect
+	golang.org/x/sync v0.1.0
+	golang.org/x/text v0.15.0
 )
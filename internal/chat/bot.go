@@ -3,42 +3,72 @@ package chat
 import (
 	"context"
 	"encoding/json"
-	"fmt"
 	"log"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/google/uuid"
 	amqp "github.com/rabbitmq/amqp091-go"
 )
 
+const (
+	// sendBuffer is how many commands can wait for the broker before new ones are dropped
+	sendBuffer = 256
+	// repliesBuffer is how many replies can wait on a busy room before new ones are dropped
+	repliesBuffer = 64
+)
+
 type botMessage struct {
-	RoomId string `json:"roomId"`
-	Msg    string `json:"msg"`
+	RoomId     string          `json:"roomId"`
+	Username   string          `json:"username,omitempty"`
+	Msg        string          `json:"msg"`
+	Data       json.RawMessage `json:"data,omitempty"`
+	Attachment *attachment     `json:"attachment,omitempty"`
+}
+
+// inbox is where the bot leaves the replies for a running room, done is
+// closed once the room stops so replies don't wait on it forever
+type inbox struct {
+	replies chan<- *botMessage
+	done    <-chan struct{}
 }
 
 type bot struct {
-	amqpCh         *amqp.Channel
-	rabbitUrl      string
-	sendQueue      string
-	receiveQueue   string
-	registerRoomId chan uuid.UUID
-	sendCh         chan *botMessage
-	roomReceiveCh  map[uuid.UUID]chan *botMessage
+	amqpCh       *amqp.Channel
+	rabbitUrl    string
+	sendQueue    string
+	receiveQueue string
+	sendCh       chan *botMessage
+
+	mu      sync.RWMutex
+	inboxes map[uuid.UUID]inbox
 }
 
 func NewBot(amqpCh *amqp.Channel, rabbitUrl string, sendQueue string, receiveQueue string) *bot {
 	return &bot{
-		amqpCh:         amqpCh,
-		rabbitUrl:      rabbitUrl,
-		sendQueue:      sendQueue,
-		receiveQueue:   receiveQueue,
-		registerRoomId: make(chan uuid.UUID),
-		sendCh:         make(chan *botMessage),
-		roomReceiveCh:  make(map[uuid.UUID]chan *botMessage),
+		amqpCh:       amqpCh,
+		rabbitUrl:    rabbitUrl,
+		sendQueue:    sendQueue,
+		receiveQueue: receiveQueue,
+		sendCh:       make(chan *botMessage, sendBuffer),
+		inboxes:      make(map[uuid.UUID]inbox),
 	}
 }
 
+// register makes the bot route the replies for roomId to replies, until done is closed
+func (b *bot) register(roomId uuid.UUID, replies chan<- *botMessage, done <-chan struct{}) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.inboxes[roomId] = inbox{replies: replies, done: done}
+}
+
+func (b *bot) unregister(roomId uuid.UUID) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	delete(b.inboxes, roomId)
+}
+
 func (b *bot) Run() {
 	log.Println("bot running...")
 	conn, err := amqp.Dial(b.rabbitUrl)
@@ -70,40 +100,70 @@ func (b *bot) Run() {
 	)
 	failOnError(err, "Failed to register a consumer")
 
-	for {
-		select {
-		case d := <-msgs:
-			log.Printf("received from queue: %s", d.Body)
-			var botMsg botMessage
-			err := json.Unmarshal(d.Body, &botMsg)
-			if err != nil {
-				log.Print("error unmarshaling msg: ", err)
-				continue
-			}
-			roomUuid, err := uuid.Parse(botMsg.RoomId)
-			if err != nil {
-				log.Print("error creating uuid: ", err)
-				continue
-			}
-			b.roomReceiveCh[roomUuid] <- &botMsg
-		case m := <-b.sendCh:
-			b.send(m)
-		case roomId := <-b.registerRoomId:
-			b.roomReceiveCh[roomId] = make(chan *botMessage)
+	// publishing on its own goroutine, a room waiting to send never holds up the replies
+	go b.runSender()
+
+	for d := range msgs {
+		log.Printf("received from queue: %s", d.Body)
+		var botMsg botMessage
+		err := json.Unmarshal(d.Body, &botMsg)
+		if err != nil {
+			log.Print("error unmarshaling msg: ", err)
+			continue
 		}
+		b.dispatch(&botMsg)
 	}
 }
 
-func (b *bot) send(message *botMessage) {
-	stockCode, err := b.extractStockTicker(message.Msg)
+// enqueue hands a command to the sender without waiting on the broker, rooms
+// must keep delivering while a publish is slow, so commands are dropped when it falls behind
+func (b *bot) enqueue(m *botMessage) {
+	select {
+	case b.sendCh <- m:
+	default:
+		log.Printf("dropping command from %s in room %s, the bot sender is behind", m.Username, m.RoomId)
+	}
+}
+
+func (b *bot) runSender() {
+	for m := range b.sendCh {
+		b.send(m)
+	}
+}
+
+// dispatch hands a reply to its room, dropping it when the room isn't running or is
+// behind, a single consumer serves every room so it can't wait on any of them
+func (b *bot) dispatch(botMsg *botMessage) {
+	roomUuid, err := uuid.Parse(botMsg.RoomId)
 	if err != nil {
+		log.Print("error creating uuid: ", err)
+		return
+	}
+
+	b.mu.RLock()
+	inbox, ok := b.inboxes[roomUuid]
+	b.mu.RUnlock()
+	if !ok {
+		// rooms live in memory, so scheduled posts may target a room from before a restart,
+		// or one that is suspended or archived
+		log.Printf("dropping message for unknown room %s", roomUuid)
 		return
 	}
 
+	select {
+	case inbox.replies <- botMsg:
+	case <-inbox.done:
+		log.Printf("dropping message for stopped room %s", roomUuid)
+	default:
+		log.Printf("dropping message for room %s, it is behind", roomUuid)
+	}
+}
+
+func (b *bot) send(message *botMessage) {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
-	body, err := json.Marshal(&botMessage{RoomId: message.RoomId, Msg: stockCode})
+	body, err := json.Marshal(message)
 	if err != nil {
 		log.Println("error encoding message for rabbitmq", err)
 		return
@@ -126,12 +186,9 @@ func (b *bot) send(message *botMessage) {
 	log.Printf("Sent to queue: %s\n", body)
 }
 
-func (b *bot) extractStockTicker(msg string) (string, error) {
-	const prefix = "/stock="
-	if strings.HasPrefix(msg, prefix) {
-		return strings.TrimPrefix(msg, prefix), nil
-	}
-	return "", fmt.Errorf("input string does not start with %s", prefix)
+// isCommand reports whether msg is meant for the bot, like /stock=aapl.us
+func isCommand(msg string) bool {
+	return strings.HasPrefix(strings.TrimSpace(msg), "/")
 }
 
 func failOnError(err error, msg string) {
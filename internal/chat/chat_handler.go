@@ -1,10 +1,14 @@
 package chat
 
 import (
+	"errors"
 	"financial-chat-api/util/auth"
 	"log"
 	"net/http"
+	"strconv"
+	"time"
 
+	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
 	"github.com/tomiok/webh"
 	"nhooyr.io/websocket"
@@ -23,50 +27,453 @@ func NewHandler(hub *hub) *handler {
 func (h *handler) HandleCreateRoom(w http.ResponseWriter, r *http.Request) error {
 	var req struct {
 		Title string `json:"title"`
+		// Visibility is public, the default, or private
+		Visibility string `json:"visibility"`
 	}
 	webh.DJson(r.Body, &req)
 
-	room, err := h.hub.createRoom(req.Title)
+	authPayload := r.Context().Value(auth.AuthorizationPayloadCtxKey).(*auth.Payload)
+
+	room, err := h.hub.createRoom(r.Context(), req.Title, authPayload.Username, req.Visibility)
+	if err != nil {
+		return toErrHTTP(err)
+	}
+
+	err = webh.EJson(w, room.info())
+	if err != nil {
+		return webh.ErrHTTP{Code: http.StatusInternalServerError, Message: err.Error()}
+	}
+	return nil
+}
+
+type ticketRes struct {
+	Ticket    string    `json:"ticket"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+// HandleCreateTicket trades the access token for a single use ticket to open a websocket
+func (h *handler) HandleCreateTicket(w http.ResponseWriter, r *http.Request) error {
+	authPayload := r.Context().Value(auth.AuthorizationPayloadCtxKey).(*auth.Payload)
+
+	value, expiresAt, err := h.hub.tickets.issue(authPayload)
 	if err != nil {
 		return webh.ErrHTTP{Code: http.StatusInternalServerError, Message: err.Error()}
 	}
 
-	err = webh.EJson(w, room)
+	err = webh.EJson(w, ticketRes{Ticket: value, ExpiresAt: expiresAt})
 	if err != nil {
 		return webh.ErrHTTP{Code: http.StatusInternalServerError, Message: err.Error()}
 	}
 	return nil
 }
 
+// authenticate takes a websocket ticket, or a bearer token like auth.Middleware does
+func (h *handler) authenticate(r *http.Request) (*auth.Payload, error) {
+	if value := wsTicket(r); value != "" {
+		payload, err := h.hub.tickets.redeem(value)
+		if err != nil {
+			return nil, webh.ErrHTTP{Code: http.StatusUnauthorized, Message: err.Error()}
+		}
+		return payload, nil
+	}
+
+	token, err := auth.BearerToken(r)
+	if err != nil {
+		return nil, webh.ErrHTTP{Code: http.StatusUnauthorized, Message: err.Error()}
+	}
+
+	payload, err := h.hub.verifier.Verify(r.Context(), token)
+	if errors.Is(err, auth.ErrRevocationCheck) {
+		return nil, webh.ErrHTTP{Code: http.StatusInternalServerError, Message: err.Error()}
+	}
+	if err != nil {
+		return nil, webh.ErrHTTP{Code: http.StatusUnauthorized, Message: err.Error()}
+	}
+	return payload, nil
+}
+
+// HandleJoinRoom opens a websocket, subscribed to the room in the roomId query param
+// when there is one. More rooms are joined with subscribe events over the socket
 func (h *handler) HandleJoinRoom(w http.ResponseWriter, r *http.Request) error {
-	roomId := r.URL.Query().Get("roomId")
-	if roomId == "" {
-		return webh.ErrHTTP{Code: http.StatusBadRequest, Message: "roomId required"}
+	authPayload, err := h.authenticate(r)
+	if err != nil {
+		return err
+	}
+
+	var room *room
+	if roomId := r.URL.Query().Get("roomId"); roomId != "" {
+		roomUuid, err := uuid.Parse(roomId)
+		if err != nil {
+			return webh.ErrHTTP{Code: http.StatusBadRequest, Message: "roomId must be uuid"}
+		}
+
+		room, err = h.hub.getRoom(roomUuid)
+		if err != nil {
+			return webh.ErrHTTP{Code: http.StatusBadRequest, Message: "room doesn't exist"}
+		}
+
+		// banned users and strangers to private rooms are turned away before upgrading, so they get a plain 403
+		err = h.hub.admit(r.Context(), room, authPayload.Username)
+		if err != nil {
+			return toErrHTTP(err)
+		}
+	}
+
+	// pages served from other hosts need to match the origin patterns
+	conn, err := websocket.Accept(w, r, &websocket.AcceptOptions{
+		OriginPatterns: h.hub.origins,
+		Subprotocols:   wsSubprotocols(r)})
+	if err != nil {
+		// Accept already answered, like with a 403 for a foreign origin
+		log.Printf("can't create websocket: %v", err)
+		return nil
+	}
+
+	c := h.hub.connect(conn, authPayload)
+	if room == nil {
+		return nil
+	}
+
+	err = h.hub.subscribe(r.Context(), c, room.ID)
+	if err != nil {
+		log.Println(err)
+		c.close(websocket.StatusInternalError, err.Error())
+	}
+
+	return nil
+}
+
+type moderationReq struct {
+	Username string `json:"username"`
+	// Duration is a go duration like 10m or 24h, bans without one are permanent
+	Duration string `json:"duration"`
+	Reason   string `json:"reason"`
+}
+
+func decodeModeration(r *http.Request) (uuid.UUID, moderationReq, time.Duration, error) {
+	var req moderationReq
+	roomId, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		return uuid.Nil, req, 0, webh.ErrHTTP{Code: http.StatusBadRequest, Message: "roomId must be uuid"}
+	}
+
+	_, err = webh.DJson(r.Body, &req)
+	if err != nil {
+		return uuid.Nil, req, 0, webh.ErrHTTP{Code: http.StatusBadRequest, Message: err.Error()}
+	}
+
+	var duration time.Duration
+	if req.Duration != "" {
+		duration, err = time.ParseDuration(req.Duration)
+		if err != nil {
+			return uuid.Nil, req, 0, webh.ErrHTTP{Code: http.StatusBadRequest, Message: "duration must be like 10m or 24h"}
+		}
+	}
+	return roomId, req, duration, nil
+}
+
+func (h *handler) HandleKick(w http.ResponseWriter, r *http.Request) error {
+	roomId, req, _, err := decodeModeration(r)
+	if err != nil {
+		return err
+	}
+
+	authPayload := r.Context().Value(auth.AuthorizationPayloadCtxKey).(*auth.Payload)
+
+	err = h.hub.Kick(r.Context(), authPayload.Username, roomId, req.Username, req.Reason)
+	if err != nil {
+		return toErrHTTP(err)
 	}
-	roomUuid, err := uuid.Parse(roomId)
+
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+func (h *handler) HandleMute(w http.ResponseWriter, r *http.Request) error {
+	roomId, req, duration, err := decodeModeration(r)
+	if err != nil {
+		return err
+	}
+
+	authPayload := r.Context().Value(auth.AuthorizationPayloadCtxKey).(*auth.Payload)
+
+	res, err := h.hub.Mute(r.Context(), authPayload.Username, roomId, req.Username, duration, req.Reason)
+	if err != nil {
+		return toErrHTTP(err)
+	}
+
+	err = webh.EJson(w, res)
+	if err != nil {
+		return webh.ErrHTTP{Code: http.StatusInternalServerError, Message: err.Error()}
+	}
+	return nil
+}
+
+func (h *handler) HandleUnmute(w http.ResponseWriter, r *http.Request) error {
+	roomId, err := uuid.Parse(chi.URLParam(r, "id"))
 	if err != nil {
 		return webh.ErrHTTP{Code: http.StatusBadRequest, Message: "roomId must be uuid"}
 	}
 
-	_, err = h.hub.getRoom(roomUuid)
+	authPayload := r.Context().Value(auth.AuthorizationPayloadCtxKey).(*auth.Payload)
+
+	err = h.hub.Unmute(r.Context(), authPayload.Username, roomId, chi.URLParam(r, "username"))
 	if err != nil {
-		return webh.ErrHTTP{Code: http.StatusBadRequest, Message: "room doesn't exist"}
+		return toErrHTTP(err)
 	}
 
-	conn, err := websocket.Accept(w, r, &websocket.AcceptOptions{
-		InsecureSkipVerify: true, //not recomended
-		Subprotocols:       []string{"chat"}})
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+func (h *handler) HandleBan(w http.ResponseWriter, r *http.Request) error {
+	roomId, req, duration, err := decodeModeration(r)
 	if err != nil {
-		return webh.ErrHTTP{Code: http.StatusInternalServerError, Message: "cant create websocket"}
+		return err
 	}
 
 	authPayload := r.Context().Value(auth.AuthorizationPayloadCtxKey).(*auth.Payload)
 
-	err = h.hub.join(conn, authPayload.Username, roomUuid)
+	res, err := h.hub.Ban(r.Context(), authPayload.Username, roomId, req.Username, duration, req.Reason)
 	if err != nil {
-		log.Println(err)
-		conn.Close(websocket.StatusInternalError, err.Error())
+		return toErrHTTP(err)
+	}
+
+	err = webh.EJson(w, res)
+	if err != nil {
+		return webh.ErrHTTP{Code: http.StatusInternalServerError, Message: err.Error()}
+	}
+	return nil
+}
+
+func (h *handler) HandleUnban(w http.ResponseWriter, r *http.Request) error {
+	roomId, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		return webh.ErrHTTP{Code: http.StatusBadRequest, Message: "roomId must be uuid"}
+	}
+
+	authPayload := r.Context().Value(auth.AuthorizationPayloadCtxKey).(*auth.Payload)
+
+	err = h.hub.Unban(r.Context(), authPayload.Username, roomId, chi.URLParam(r, "username"))
+	if err != nil {
+		return toErrHTTP(err)
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+func (h *handler) HandleAddModerator(w http.ResponseWriter, r *http.Request) error {
+	return h.setModerator(w, r, true)
+}
+
+func (h *handler) HandleRemoveModerator(w http.ResponseWriter, r *http.Request) error {
+	return h.setModerator(w, r, false)
+}
+
+func (h *handler) setModerator(w http.ResponseWriter, r *http.Request, moderator bool) error {
+	roomId, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		return webh.ErrHTTP{Code: http.StatusBadRequest, Message: "roomId must be uuid"}
+	}
+
+	authPayload := r.Context().Value(auth.AuthorizationPayloadCtxKey).(*auth.Payload)
+
+	err = h.hub.SetModerator(r.Context(), authPayload.Username, roomId, chi.URLParam(r, "username"), moderator)
+	if err != nil {
+		return toErrHTTP(err)
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+func (h *handler) HandleGetRoom(w http.ResponseWriter, r *http.Request) error {
+	roomId, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		return webh.ErrHTTP{Code: http.StatusBadRequest, Message: "roomId must be uuid"}
+	}
+
+	authPayload := r.Context().Value(auth.AuthorizationPayloadCtxKey).(*auth.Payload)
+
+	res, err := h.hub.RoomInfo(r.Context(), authPayload.Username, roomId)
+	if err != nil {
+		return toErrHTTP(err)
+	}
+
+	err = webh.EJson(w, res)
+	if err != nil {
+		return webh.ErrHTTP{Code: http.StatusInternalServerError, Message: err.Error()}
+	}
+	return nil
+}
+
+func (h *handler) HandleUpdateRoom(w http.ResponseWriter, r *http.Request) error {
+	roomId, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		return webh.ErrHTTP{Code: http.StatusBadRequest, Message: "roomId must be uuid"}
+	}
+
+	var req roomUpdate
+	_, err = webh.DJson(r.Body, &req)
+	if err != nil {
+		return webh.ErrHTTP{Code: http.StatusBadRequest, Message: err.Error()}
+	}
+
+	authPayload := r.Context().Value(auth.AuthorizationPayloadCtxKey).(*auth.Payload)
+
+	res, err := h.hub.UpdateRoom(r.Context(), authPayload.Username, roomId, req)
+	if err != nil {
+		return toErrHTTP(err)
+	}
+
+	err = webh.EJson(w, res)
+	if err != nil {
+		return webh.ErrHTTP{Code: http.StatusInternalServerError, Message: err.Error()}
+	}
+	return nil
+}
+
+func (h *handler) HandleArchiveRoom(w http.ResponseWriter, r *http.Request) error {
+	roomId, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		return webh.ErrHTTP{Code: http.StatusBadRequest, Message: "roomId must be uuid"}
+	}
+
+	authPayload := r.Context().Value(auth.AuthorizationPayloadCtxKey).(*auth.Payload)
+
+	res, err := h.hub.ArchiveRoom(r.Context(), authPayload.Username, roomId)
+	if err != nil {
+		return toErrHTTP(err)
+	}
+
+	err = webh.EJson(w, res)
+	if err != nil {
+		return webh.ErrHTTP{Code: http.StatusInternalServerError, Message: err.Error()}
+	}
+	return nil
+}
+
+func (h *handler) HandleDeleteRoom(w http.ResponseWriter, r *http.Request) error {
+	roomId, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		return webh.ErrHTTP{Code: http.StatusBadRequest, Message: "roomId must be uuid"}
+	}
+
+	authPayload := r.Context().Value(auth.AuthorizationPayloadCtxKey).(*auth.Payload)
+
+	err = h.hub.DeleteRoom(r.Context(), authPayload.Username, roomId)
+	if err != nil {
+		return toErrHTTP(err)
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+func (h *handler) HandleInvite(w http.ResponseWriter, r *http.Request) error {
+	roomId, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		return webh.ErrHTTP{Code: http.StatusBadRequest, Message: "roomId must be uuid"}
+	}
+
+	var req struct {
+		Username string `json:"username"`
+	}
+	_, err = webh.DJson(r.Body, &req)
+	if err != nil {
+		return webh.ErrHTTP{Code: http.StatusBadRequest, Message: err.Error()}
+	}
+
+	authPayload := r.Context().Value(auth.AuthorizationPayloadCtxKey).(*auth.Payload)
+
+	res, err := h.hub.Invite(r.Context(), authPayload.Username, roomId, req.Username)
+	if err != nil {
+		return toErrHTTP(err)
+	}
+
+	err = webh.EJson(w, res)
+	if err != nil {
+		return webh.ErrHTTP{Code: http.StatusInternalServerError, Message: err.Error()}
+	}
+	return nil
+}
+
+func (h *handler) HandleListInvites(w http.ResponseWriter, r *http.Request) error {
+	authPayload := r.Context().Value(auth.AuthorizationPayloadCtxKey).(*auth.Payload)
+
+	res, err := h.hub.Invites(r.Context(), authPayload.Username)
+	if err != nil {
+		return toErrHTTP(err)
+	}
+
+	err = webh.EJson(w, res)
+	if err != nil {
+		return webh.ErrHTTP{Code: http.StatusInternalServerError, Message: err.Error()}
+	}
+	return nil
+}
+
+func (h *handler) HandleAcceptInvite(w http.ResponseWriter, r *http.Request) error {
+	id, err := strconv.ParseInt(chi.URLParam(r, "inviteId"), 10, 64)
+	if err != nil {
+		return webh.ErrHTTP{Code: http.StatusBadRequest, Message: "inviteId must be a number"}
+	}
+
+	authPayload := r.Context().Value(auth.AuthorizationPayloadCtxKey).(*auth.Payload)
+
+	room, err := h.hub.AcceptInvite(r.Context(), authPayload.Username, id)
+	if err != nil {
+		return toErrHTTP(err)
 	}
 
+	err = webh.EJson(w, room.info())
+	if err != nil {
+		return webh.ErrHTTP{Code: http.StatusInternalServerError, Message: err.Error()}
+	}
 	return nil
 }
+
+func (h *handler) HandleOpenDM(w http.ResponseWriter, r *http.Request) error {
+	authPayload := r.Context().Value(auth.AuthorizationPayloadCtxKey).(*auth.Payload)
+
+	room, err := h.hub.OpenDM(r.Context(), authPayload.Username, chi.URLParam(r, "username"))
+	if err != nil {
+		return toErrHTTP(err)
+	}
+
+	err = webh.EJson(w, room.info())
+	if err != nil {
+		return webh.ErrHTTP{Code: http.StatusInternalServerError, Message: err.Error()}
+	}
+	return nil
+}
+
+func (h *handler) HandleListDMs(w http.ResponseWriter, r *http.Request) error {
+	authPayload := r.Context().Value(auth.AuthorizationPayloadCtxKey).(*auth.Payload)
+
+	res, err := h.hub.Conversations(r.Context(), authPayload.Username)
+	if err != nil {
+		return toErrHTTP(err)
+	}
+
+	err = webh.EJson(w, res)
+	if err != nil {
+		return webh.ErrHTTP{Code: http.StatusInternalServerError, Message: err.Error()}
+	}
+	return nil
+}
+
+func toErrHTTP(err error) error {
+	switch {
+	case errors.Is(err, errInvalidModeration), errors.Is(err, errInvalidRoom), errors.Is(err, errInvalidInvite):
+		return webh.ErrHTTP{Code: http.StatusBadRequest, Message: err.Error()}
+	case errors.Is(err, errForbidden):
+		return webh.ErrHTTP{Code: http.StatusForbidden, Message: err.Error()}
+	case errors.Is(err, errRoomNotFound), errors.Is(err, errInviteNotFound), errors.Is(err, errUserNotFound):
+		return webh.ErrHTTP{Code: http.StatusNotFound, Message: err.Error()}
+	case errors.Is(err, errRoomArchived):
+		return webh.ErrHTTP{Code: http.StatusConflict, Message: err.Error()}
+	}
+	return webh.ErrHTTP{Code: http.StatusInternalServerError, Message: err.Error()}
+}
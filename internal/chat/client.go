@@ -2,28 +2,106 @@ package chat
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"financial-chat-api/util/auth"
 	"log"
+	"sync"
+	"time"
 
+	"github.com/google/uuid"
 	"nhooyr.io/websocket"
 	"nhooyr.io/websocket/wsjson"
 )
 
+const (
+	messageTypeText       = "text"
+	messageTypeAttachment = "attachment"
+	messageTypeError      = "error"
+	// sent by clients to start and stop receiving the messages of a room,
+	// the server answers with subscribed and unsubscribed
+	messageTypeSubscribe    = "subscribe"
+	messageTypeUnsubscribe  = "unsubscribe"
+	messageTypeSubscribed   = "subscribed"
+	messageTypeUnsubscribed = "unsubscribed"
+
+	errCodeUnknownRoom  = "unknown_room"
+	errCodeInvalidEvent = "invalid_event"
+	errCodeArchived     = "archived"
+
+	// receiveBuffer is how many messages a client can fall behind before it is disconnected
+	receiveBuffer = 256
+	// writeTimeout bounds every write, a client that stops reading must not hold up its rooms
+	writeTimeout = 10 * time.Second
+)
+
+var errClientClosed = errors.New("connection closed")
+
 type message struct {
-	Username string `json:"username"`
-	Msg      string `json:"msg"`
+	Type       string          `json:"type"`
+	Code       string          `json:"code,omitempty"`
+	RoomId     string          `json:"roomId,omitempty"`
+	Username   string          `json:"username"`
+	Msg        string          `json:"msg"`
+	Data       json.RawMessage `json:"data,omitempty"`
+	Attachment *attachment     `json:"attachment,omitempty"`
+	// Token is only read from reauth events
+	Token string `json:"token,omitempty"`
 }
 
+// attachment is a file sent along a message, like the charts drawn by the bot.
+// Data is base64 encoded in json
+type attachment struct {
+	Name        string `json:"name"`
+	ContentType string `json:"contentType"`
+	Data        []byte `json:"data"`
+}
+
+// client is a websocket connection of a user, subscribed to any number of rooms
 type client struct {
-	username    string
-	conn        *websocket.Conn
-	currentRoom *room
-	receive     chan *message
+	username string
+	conn     *websocket.Conn
+	receive  chan *message
+	hub      *hub
+	// done is closed once the connection is going away, so rooms stop delivering to it
+	done      chan struct{}
+	closeOnce sync.Once
+
+	// reauthed wakes up the expiry watcher when the token is replaced
+	reauthed chan struct{}
+
+	mu    sync.Mutex
+	rooms map[uuid.UUID]*room
+	// tokenId and expiresAt are the token the connection is authorized with,
+	// revoking it closes the connection and so does letting it expire
+	tokenId   uuid.UUID
+	expiresAt time.Time
+	// closeStatus and closeReason are sent when the connection is closed by the server, like on a kick
+	closeStatus websocket.StatusCode
+	closeReason string
+}
+
+func newClient(h *hub, conn *websocket.Conn, payload *auth.Payload) *client {
+	return &client{
+		username:  payload.Username,
+		conn:      conn,
+		receive:   make(chan *message, receiveBuffer),
+		hub:       h,
+		done:      make(chan struct{}),
+		reauthed:  make(chan struct{}, 1),
+		rooms:     make(map[uuid.UUID]*room),
+		tokenId:   payload.ID,
+		expiresAt: payload.ExpiredAt,
+	}
 }
 
 func (c *client) readPump() {
 	defer func() {
-		c.currentRoom.leave <- c
-		c.conn.Close(websocket.StatusInternalError, "read pump internal error")
+		c.close(websocket.StatusInternalError, "read pump internal error")
+		for _, room := range c.subscriptions() {
+			room.removeClient(c)
+		}
+		c.hub.disconnected(c)
 	}()
 
 	ctx := context.Background()
@@ -35,22 +113,212 @@ func (c *client) readPump() {
 			log.Printf("error reading message from pump: %v", err)
 			return
 		}
-		message.Username = c.username
 
-		c.currentRoom.broadcast <- &message
+		switch message.Type {
+		case messageTypeSubscribe:
+			c.subscribe(ctx, message.RoomId)
+		case messageTypeUnsubscribe:
+			c.unsubscribe(ctx, message.RoomId)
+		case messageTypeReauth:
+			c.reauth(ctx, message.Token)
+		case "", messageTypeText:
+			c.say(ctx, &message)
+		default:
+			c.sendError(ctx, message.RoomId, errCodeInvalidEvent, "unknown event type "+message.Type)
+		}
+	}
+}
+
+// say broadcasts a chat message to the room it is tagged with
+func (c *client) say(ctx context.Context, message *message) {
+	room, ok := c.roomFor(message.RoomId)
+	if !ok {
+		c.sendError(ctx, message.RoomId, errCodeUnknownRoom, "subscribe to the room before sending messages to it")
+		return
+	}
+
+	// only the bot sends structured data and attachments
+	message.Type = messageTypeText
+	message.Code = ""
+	message.RoomId = room.ID.String()
+	message.Username = c.username
+	message.Data = nil
+	message.Attachment = nil
+
+	msg, code, reason := normalizeMessage(message.Msg, c.hub.maxLength)
+	if code != "" {
+		c.sendError(ctx, message.RoomId, code, reason)
+		return
+	}
+	message.Msg = msg
+
+	// moderation commands count as commands too, and muted users can't run them
+	if until, muted := room.mutedUntil(c.username); muted {
+		c.sendError(ctx, message.RoomId, errCodeMuted, "you are muted in this room until "+until.Format(time.RFC3339))
+		return
+	}
+
+	if ok, reason := c.hub.limiter.allow(c.username, room.ID, message.Msg); !ok {
+		c.sendError(ctx, message.RoomId, errCodeRateLimited, reason)
+		return
+	}
+
+	if c.moderate(ctx, room, message.Msg) {
+		return
+	}
+
+	err := room.post(message)
+	if err != nil {
+		c.sendError(ctx, message.RoomId, errCodeArchived, err.Error())
+	}
+}
+
+func (c *client) subscribe(ctx context.Context, roomId string) {
+	id, err := uuid.Parse(roomId)
+	if err != nil {
+		c.sendError(ctx, roomId, errCodeUnknownRoom, "roomId must be uuid")
+		return
+	}
+
+	err = c.hub.subscribe(ctx, c, id)
+	switch {
+	case errors.Is(err, errForbidden):
+		c.sendError(ctx, roomId, errCodeForbidden, err.Error())
+	case errors.Is(err, errRoomNotFound):
+		c.sendError(ctx, roomId, errCodeUnknownRoom, err.Error())
+	case errors.Is(err, errRoomArchived):
+		c.sendError(ctx, roomId, errCodeArchived, err.Error())
+	case err != nil:
+		log.Printf("error subscribing to room %s: %v", roomId, err)
+		c.sendError(ctx, roomId, errCodeUnknownRoom, "can't subscribe to the room")
+	}
+}
+
+func (c *client) unsubscribe(ctx context.Context, roomId string) {
+	room, ok := c.roomFor(roomId)
+	if !ok {
+		c.sendError(ctx, roomId, errCodeUnknownRoom, "not subscribed to the room")
+		return
+	}
+
+	c.mu.Lock()
+	delete(c.rooms, room.ID)
+	c.mu.Unlock()
+
+	room.removeClient(c)
+	c.deliver(&message{Type: messageTypeUnsubscribed, RoomId: room.ID.String()})
+}
+
+// roomFor returns the subscribed room with roomId, which may be left empty
+// when the client is subscribed to a single room
+func (c *client) roomFor(roomId string) (*room, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if roomId == "" {
+		if len(c.rooms) != 1 {
+			return nil, false
+		}
+		for _, room := range c.rooms {
+			return room, true
+		}
+	}
+
+	id, err := uuid.Parse(roomId)
+	if err != nil {
+		return nil, false
+	}
+	room, ok := c.rooms[id]
+	return room, ok
+}
+
+func (c *client) subscriptions() []*room {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	rooms := make([]*room, 0, len(c.rooms))
+	for _, room := range c.rooms {
+		rooms = append(rooms, room)
+	}
+	return rooms
+}
+
+// removed is called by a room that dropped the client, like on a kick. The
+// connection is closed with status and reason when it was the last room of the client
+func (c *client) removed(room *room, status websocket.StatusCode, code string, reason string) {
+	c.mu.Lock()
+	delete(c.rooms, room.ID)
+	left := len(c.rooms)
+	c.mu.Unlock()
+
+	if left == 0 {
+		c.close(status, reason)
+		return
 	}
+	c.deliver(&message{Type: messageTypeUnsubscribed, Code: code, RoomId: room.ID.String(), Msg: reason})
+}
+
+// deliver queues m for the write pump, unless the connection is going away. Rooms
+// deliver from their run loop, so a client too far behind is closed instead of waited on
+func (c *client) deliver(m *message) {
+	select {
+	case c.receive <- m:
+	case <-c.done:
+	default:
+		log.Printf("closing connection of %s, it fell behind", c.username)
+		c.close(websocket.StatusPolicyViolation, "too slow reading messages")
+	}
+}
+
+// close stops the write pump, which closes the connection with status and reason.
+// Only the first call counts
+func (c *client) close(status websocket.StatusCode, reason string) {
+	c.closeOnce.Do(func() {
+		c.mu.Lock()
+		c.closeStatus, c.closeReason = status, reason
+		c.mu.Unlock()
+		close(c.done)
+	})
 }
 
 func (c *client) writePump() {
-	defer c.conn.Close(websocket.StatusInternalError, "write pump internal error")
+	defer func() {
+		c.mu.Lock()
+		status, reason := c.closeStatus, c.closeReason
+		c.mu.Unlock()
+		c.conn.Close(status, reason)
+	}()
 
-	for m := range c.receive {
-		err := wsjson.Write(context.Background(), c.conn, m)
-		if err != nil {
-			log.Printf("error writing message to pump: %v", err)
+	for {
+		select {
+		case m := <-c.receive:
+			err := c.write(m)
+			if err != nil {
+				log.Printf("error writing message to pump: %v", err)
+				c.close(websocket.StatusInternalError, "write pump internal error")
+				return
+			}
+		case <-c.done:
+			log.Println("client connection closed")
 			return
 		}
 	}
+}
 
-	log.Println("client receive channel closed")
+func (c *client) write(m *message) error {
+	ctx, cancel := context.WithTimeout(context.Background(), writeTimeout)
+	defer cancel()
+	return wsjson.Write(ctx, c.conn, m)
+}
+
+// sendError tells only this client that one of its messages was rejected,
+// the connection stays open
+func (c *client) sendError(ctx context.Context, roomId string, code string, msg string) {
+	ctx, cancel := context.WithTimeout(ctx, writeTimeout)
+	defer cancel()
+
+	err := wsjson.Write(ctx, c.conn, &message{Type: messageTypeError, Code: code, RoomId: roomId, Msg: msg})
+	if err != nil {
+		log.Printf("error writing error event: %v", err)
+	}
 }
@@ -0,0 +1,71 @@
+package chat
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// dmNamespace seeds the room ids of direct messages, so a pair of users always
+// gets the same room, even after a restart
+var dmNamespace = uuid.MustParse("01279053-e333-44d3-83c1-2a0e4e2e3c3d")
+
+// conversation is a direct message room as seen by one of its two users
+type conversation struct {
+	RoomID    uuid.UUID `json:"roomId"`
+	With      string    `json:"with"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// dmPair sorts the usernames of a direct message, so both users get the same room
+func dmPair(a string, b string) (string, string) {
+	if a > b {
+		return b, a
+	}
+	return a, b
+}
+
+func dmRoomID(a string, b string) uuid.UUID {
+	a, b = dmPair(a, b)
+	return uuid.NewSHA1(dmNamespace, []byte(a+"\x00"+b))
+}
+
+// OpenDM returns the direct message room between username and other, creating it
+// the first time. It is a private room without owner whose only members are both users
+func (h *hub) OpenDM(ctx context.Context, username string, other string) (*room, error) {
+	if other == "" || other == username {
+		return nil, fmt.Errorf("%w: you can't message yourself", errInvalidRoom)
+	}
+
+	h.dmMu.Lock()
+	defer h.dmMu.Unlock()
+
+	id := dmRoomID(username, other)
+	if room, err := h.getRoom(id); err == nil {
+		return room, nil
+	}
+
+	err := h.repo.AddConversation(ctx, id, username, other)
+	if err != nil {
+		return nil, err
+	}
+	for _, member := range []string{username, other} {
+		err = h.repo.AddMember(ctx, id, member, roleMember)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	a, b := dmPair(username, other)
+	room := newRoom(id, a+", "+b, "", visibilityPrivate, h.bot, h.idleTimeout)
+	h.addRoom(room)
+
+	return room, nil
+}
+
+// Conversations lists the direct messages of username, the most recent first
+func (h *hub) Conversations(ctx context.Context, username string) ([]conversation, error) {
+	return h.repo.Conversations(ctx, username)
+}
@@ -1,85 +1,227 @@
 package chat
 
 import (
-	"errors"
-	"log"
+	"context"
+	"financial-chat-api/util/auth"
+	"financial-chat-api/util/config"
+	"slices"
+	"sync"
+	"time"
 
 	"github.com/google/uuid"
 	"nhooyr.io/websocket"
 )
 
+// hub keeps the rooms and connections, read by every request and connection
 type hub struct {
-	rooms    map[uuid.UUID]*room
-	register chan *client
-	addRoom  chan *room
-	bot      *bot
+	mu      sync.RWMutex
+	rooms   map[uuid.UUID]*room
+	clients map[*client]struct{}
+	bot     *bot
+	repo    roomRepo
+	limiter *limiter
+	// readLimit caps the bytes of a single websocket frame, maxLength the characters of a message
+	readLimit int64
+	maxLength int
+	// idleTimeout is how long a room without clients keeps running
+	idleTimeout time.Duration
+	// verifier checks the tokens sent by clients to stay connected past the expiry of the first one
+	verifier     tokenVerifier
+	reauthNotice time.Duration
+	tickets      *tickets
+	// origins are the host patterns of the web pages allowed to open websockets, besides the server host
+	origins []string
+
+	// dmMu keeps two requests for the same pair from starting its room twice
+	dmMu sync.Mutex
 }
 
-func NewHub(bot *bot) *hub {
+func NewHub(bot *bot, cfg *config.Config, repo roomRepo, verifier tokenVerifier) *hub {
 	return &hub{
-		rooms:    make(map[uuid.UUID]*room),
-		register: make(chan *client),
-		addRoom:  make(chan *room),
-		bot:      bot,
+		rooms:   make(map[uuid.UUID]*room),
+		clients: make(map[*client]struct{}),
+		bot:     bot,
+		repo:    repo,
+		limiter: newLimiter(
+			rateLimit{perMinute: cfg.MessagesPerMinute, burst: cfg.MessageBurst},
+			rateLimit{perMinute: cfg.CommandsPerMinute, burst: cfg.CommandBurst},
+			rateLimit{perMinute: cfg.RoomCommandsPerMinute, burst: cfg.RoomCommandBurst}),
+		readLimit:    cfg.MaxFrameBytes,
+		maxLength:    cfg.MaxMessageLength,
+		idleTimeout:  cfg.RoomIdleTimeout,
+		verifier:     verifier,
+		reauthNotice: reauthNotice,
+		tickets:      newTickets(),
+		origins:      cfg.AllowedOrigins,
 	}
 }
 
-func (h *hub) Run() {
-	log.Println("hub running...")
-	for {
-		select {
-		case c := <-h.register:
-			h.rooms[c.currentRoom.ID].join <- c
-		case r := <-h.addRoom:
-			h.rooms[r.ID] = r
-		}
-
+func (h *hub) createRoom(ctx context.Context, title string, owner string, visibility string) (*room, error) {
+	visibility, err := validVisibility(visibility)
+	if err != nil {
+		return nil, err
 	}
-}
 
-func (h *hub) createRoom(title string) (*room, error) {
+	id, err := uuid.NewRandom()
+	if err != nil {
+		return nil, err
+	}
+	room := newRoom(id, title, owner, visibility, h.bot, h.idleTimeout)
 
-	room, err := newRoom(title, h.bot)
+	err = h.repo.SetRole(ctx, room.ID, owner, roleOwner)
 	if err != nil {
 		return nil, err
 	}
 
-	h.bot.registerRoomId <- room.ID
+	h.addRoom(room)
 
-	go room.run()
+	return room, nil
+}
 
-	h.addRoom <- room
+func (h *hub) addRoom(room *room) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.rooms[room.ID] = room
+}
 
-	return room, nil
+func (h *hub) removeRoom(roomId uuid.UUID) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	delete(h.rooms, roomId)
 }
 
 func (h *hub) getRoom(roomId uuid.UUID) (*room, error) {
+	h.mu.RLock()
 	room, ok := h.rooms[roomId]
+	h.mu.RUnlock()
 	if !ok {
-		return nil, errors.New("room doesn't exist")
+		return nil, errRoomNotFound
 	}
 
 	return room, nil
 }
 
-func (h *hub) join(conn *websocket.Conn, username string, roomId uuid.UUID) error {
+// RoomOwner returns the username of the user who created the room
+func (h *hub) RoomOwner(roomId uuid.UUID) (string, error) {
+	room, err := h.getRoom(roomId)
+	if err != nil {
+		return "", err
+	}
+
+	return room.Owner, nil
+}
+
+// admit fails when username can't join the room, because it is archived,
+// of a ban or not being a member of a private room
+func (h *hub) admit(ctx context.Context, room *room, username string) error {
+	if room.info().Archived {
+		return errRoomArchived
+	}
+
+	err := h.checkBan(ctx, room.ID, username)
+	if err != nil {
+		return err
+	}
+
+	return h.checkAccess(ctx, room, username)
+}
+
+// connect starts the pumps of a new websocket connection, without any subscription
+func (h *hub) connect(conn *websocket.Conn, payload *auth.Payload) *client {
+	// frames over the limit fail the read and close the connection with StatusMessageTooBig
+	conn.SetReadLimit(h.readLimit)
 
+	c := newClient(h, conn, payload)
+
+	h.mu.Lock()
+	h.clients[c] = struct{}{}
+	h.mu.Unlock()
+
+	go c.readPump()
+	go c.writePump()
+	go c.watchExpiry()
+
+	return c
+}
+
+func (h *hub) disconnected(c *client) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	delete(h.clients, c)
+}
+
+// DisconnectTokens closes the connections opened with any of the revoked tokens
+func (h *hub) DisconnectTokens(tokenIds []uuid.UUID) {
+	h.tickets.revoke(func(payload *auth.Payload) bool {
+		return slices.Contains(tokenIds, payload.ID)
+	})
+	h.disconnect(func(c *client) bool {
+		tokenId, _ := c.token()
+		return slices.Contains(tokenIds, tokenId)
+	})
+}
+
+// DisconnectUser closes every connection of username, whatever token it was opened with
+func (h *hub) DisconnectUser(username string) {
+	h.tickets.revoke(func(payload *auth.Payload) bool {
+		return payload.Username == username
+	})
+	h.disconnect(func(c *client) bool {
+		return c.username == username
+	})
+}
+
+func (h *hub) disconnect(match func(c *client) bool) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	for c := range h.clients {
+		if match(c) {
+			c.close(websocket.StatusPolicyViolation, "session revoked")
+		}
+	}
+}
+
+// subscribe makes the room deliver its messages to the client
+func (h *hub) subscribe(ctx context.Context, c *client, roomId uuid.UUID) error {
 	room, err := h.getRoom(roomId)
 	if err != nil {
 		return err
 	}
 
-	newClient := &client{
-		username:    username,
-		conn:        conn,
-		currentRoom: room,
-		receive:     make(chan *message),
+	err = h.admit(ctx, room, c.username)
+	if err != nil {
+		return err
 	}
 
-	h.register <- newClient
+	err = h.loadMute(ctx, room, c.username)
+	if err != nil {
+		return err
+	}
 
-	go newClient.readPump()
-	go newClient.writePump()
+	c.mu.Lock()
+	c.rooms[room.ID] = room
+	c.mu.Unlock()
+
+	err = room.addClient(c)
+	if err != nil {
+		c.mu.Lock()
+		delete(c.rooms, room.ID)
+		c.mu.Unlock()
+		return err
+	}
+
+	// a connection closing meanwhile may have left its rooms before this one was added,
+	// a dead client would keep the room from ever going idle
+	select {
+	case <-c.done:
+		c.mu.Lock()
+		delete(c.rooms, room.ID)
+		c.mu.Unlock()
+		room.removeClient(c)
+		return errClientClosed
+	default:
+	}
+	c.deliver(&message{Type: messageTypeSubscribed, RoomId: room.ID.String(), Msg: room.info().Title})
 
 	return nil
 }
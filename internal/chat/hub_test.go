@@ -0,0 +1,631 @@
+package chat
+
+import (
+	"context"
+	"financial-chat-api/util/auth"
+	"financial-chat-api/util/config"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"nhooyr.io/websocket"
+	"nhooyr.io/websocket/wsjson"
+)
+
+// these tests are meant to run with -race, they exercise the hub, rooms and
+// bot from many goroutines at once
+
+// memRepo keeps roles in memory, everything else is a no-op
+type memRepo struct {
+	mu    sync.Mutex
+	roles map[string]string
+}
+
+func newMemRepo() *memRepo {
+	return &memRepo{roles: make(map[string]string)}
+}
+
+func (m *memRepo) SetRole(ctx context.Context, roomId uuid.UUID, username string, role string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.roles[roomId.String()+"/"+username] = role
+	return nil
+}
+
+func (m *memRepo) GetRole(ctx context.Context, roomId uuid.UUID, username string) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.roles[roomId.String()+"/"+username], nil
+}
+
+func (m *memRepo) AddSanction(ctx context.Context, s sanction) (sanction, error) {
+	return s, nil
+}
+
+func (m *memRepo) ActiveSanction(ctx context.Context, roomId uuid.UUID, username string, kind string) (*sanction, error) {
+	return nil, nil
+}
+
+func (m *memRepo) RemoveSanctions(ctx context.Context, roomId uuid.UUID, username string, kind string) (bool, error) {
+	return false, nil
+}
+
+func (m *memRepo) AddMember(ctx context.Context, roomId uuid.UUID, username string, role string) error {
+	return m.SetRole(ctx, roomId, username, role)
+}
+
+func (m *memRepo) CreateInvite(ctx context.Context, inv invite) (invite, error) {
+	return inv, nil
+}
+
+func (m *memRepo) GetInvite(ctx context.Context, id int64) (*invite, error) {
+	return nil, nil
+}
+
+func (m *memRepo) UserInvites(ctx context.Context, username string) ([]invite, error) {
+	return nil, nil
+}
+
+func (m *memRepo) DeleteInvite(ctx context.Context, id int64) error {
+	return nil
+}
+
+func (m *memRepo) AddConversation(ctx context.Context, roomId uuid.UUID, a string, b string) error {
+	return nil
+}
+
+func (m *memRepo) Conversations(ctx context.Context, username string) ([]conversation, error) {
+	return nil, nil
+}
+
+func (m *memRepo) DeleteRoom(ctx context.Context, roomId uuid.UUID) error {
+	return nil
+}
+
+// noRevocations is a revocation store where nothing is revoked
+type noRevocations struct{}
+
+func (noRevocations) IsRevoked(ctx context.Context, tokenId uuid.UUID) (bool, error) {
+	return false, nil
+}
+
+// testTokens signs the tokens sent in reauth events
+var testTokens, _ = auth.NewPasetoMaker("12345678901234567890123456789012")
+
+// newTestHub returns a hub without rabbitmq, plus the url of a websocket server.
+// Requests with a u query param get a ticket for that user, with the token id
+// from token and the token lifetime from ttl, an hour by default
+func newTestHub(t *testing.T, idleTimeout time.Duration) (*hub, *bot, string) {
+	t.Helper()
+
+	b := NewBot(nil, "", "", "")
+	// rooms only queue commands for the sender, which would publish them on the nil
+	// channel, so tests must stick to plain messages and dispatch replies directly
+	go b.runSender()
+
+	h := NewHub(b, &config.Config{
+		MaxFrameBytes:    8192,
+		MaxMessageLength: 1000,
+		RoomIdleTimeout:  idleTimeout,
+	}, newMemRepo(), auth.NewVerifier(testTokens, noRevocations{}))
+	handler := NewHandler(h)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		if username := query.Get("u"); username != "" {
+			ttl, err := time.ParseDuration(query.Get("ttl"))
+			if err != nil {
+				ttl = time.Hour
+			}
+			payload := &auth.Payload{Username: username, ExpiredAt: time.Now().Add(ttl)}
+			payload.ID, _ = uuid.Parse(query.Get("token"))
+
+			value, _, err := h.tickets.issue(payload)
+			if err != nil {
+				t.Errorf("issue ticket: %v", err)
+				return
+			}
+			query.Set(ticketParam, value)
+			r.URL.RawQuery = query.Encode()
+		}
+
+		err := handler.HandleJoinRoom(w, r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	return h, b, "ws" + strings.TrimPrefix(server.URL, "http")
+}
+
+func dial(t *testing.T, url string, username string, roomId uuid.UUID) *websocket.Conn {
+	return dialToken(t, url, username, uuid.Nil, roomId)
+}
+
+func dialToken(t *testing.T, url string, username string, tokenId uuid.UUID, roomId uuid.UUID) *websocket.Conn {
+	t.Helper()
+
+	conn := connect(t, url, fmt.Sprintf("u=%s&token=%s&roomId=%s", username, tokenId, roomId))
+	if conn == nil {
+		return nil
+	}
+
+	// once subscribed the client is in the room, so everything sent later reaches it
+	_, err := readType(conn, messageTypeSubscribed)
+	if err != nil {
+		t.Errorf("subscribe %s: %v", username, err)
+		return nil
+	}
+	return conn
+}
+
+// connect opens a websocket with the query params in query, without waiting for anything
+func connect(t *testing.T, url string, query string) *websocket.Conn {
+	t.Helper()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	conn, _, err := websocket.Dial(ctx, url+"?"+query, nil)
+	if err != nil {
+		t.Errorf("dial %s: %v", query, err)
+		return nil
+	}
+	t.Cleanup(func() { conn.Close(websocket.StatusNormalClosure, "") })
+	return conn
+}
+
+// readType skips messages until one of type typ, a bot reply may get ahead
+// of the subscribed event for instance
+func readType(conn *websocket.Conn, typ string) (message, error) {
+	for {
+		m, err := read(conn)
+		if err != nil || m.Type == typ {
+			return m, err
+		}
+	}
+}
+
+func read(conn *websocket.Conn) (message, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var m message
+	err := wsjson.Read(ctx, conn, &m)
+	return m, err
+}
+
+func TestCreateRoomsConcurrently(t *testing.T) {
+	h, _, _ := newTestHub(t, 0)
+
+	const rooms = 50
+	ids := make(chan uuid.UUID, rooms)
+	var wg sync.WaitGroup
+	for i := 0; i < rooms; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			room, err := h.createRoom(context.Background(), fmt.Sprintf("room %d", i), "owner", "")
+			if err != nil {
+				t.Errorf("create room: %v", err)
+				return
+			}
+			ids <- room.ID
+		}()
+		// lookups race with the creations
+		go func() {
+			defer wg.Done()
+			h.getRoom(uuid.New())
+		}()
+	}
+	wg.Wait()
+	close(ids)
+
+	for id := range ids {
+		owner, err := h.RoomOwner(id)
+		if err != nil || owner != "owner" {
+			t.Errorf("room %s: owner %q, %v", id, owner, err)
+		}
+	}
+}
+
+func TestJoinAndRouteBotRepliesConcurrently(t *testing.T) {
+	h, b, url := newTestHub(t, 0)
+
+	const (
+		rooms   = 5
+		clients = 4
+		replies = 10
+	)
+
+	var created []*room
+	for i := 0; i < rooms; i++ {
+		room, err := h.createRoom(context.Background(), fmt.Sprintf("room %d", i), "owner", "")
+		if err != nil {
+			t.Fatalf("create room: %v", err)
+		}
+		created = append(created, room)
+	}
+
+	conns := make([][]*websocket.Conn, rooms)
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for i, room := range created {
+		for j := 0; j < clients; j++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				conn := dial(t, url, fmt.Sprintf("user%d-%d", i, j), room.ID)
+				mu.Lock()
+				conns[i] = append(conns[i], conn)
+				mu.Unlock()
+			}()
+		}
+	}
+	wg.Wait()
+	if t.Failed() {
+		t.FailNow()
+	}
+
+	for _, room := range created {
+		for k := 0; k < replies; k++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				b.dispatch(&botMessage{RoomId: room.ID.String(), Msg: fmt.Sprintf("reply %d", k)})
+			}()
+		}
+	}
+
+	for i, room := range created {
+		for _, conn := range conns[i] {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for k := 0; k < replies; k++ {
+					m, err := read(conn)
+					if err != nil {
+						t.Errorf("read reply: %v", err)
+						return
+					}
+					if m.Username != "BOT" || m.RoomId != room.ID.String() {
+						t.Errorf("got %+v for room %s", m, room.ID)
+					}
+				}
+			}()
+		}
+	}
+	wg.Wait()
+}
+
+func TestSlowClientDoesNotStallRoom(t *testing.T) {
+	h, _, url := newTestHub(t, 0)
+
+	room, err := h.createRoom(context.Background(), "room", "owner", "")
+	if err != nil {
+		t.Fatalf("create room: %v", err)
+	}
+	conn := dial(t, url, "reader", room.ID)
+
+	// nothing ever writes to this client, like a peer that stopped reading
+	slow := newClient(h, nil, &auth.Payload{Username: "slow", ExpiredAt: time.Now().Add(time.Hour)})
+	err = h.subscribe(context.Background(), slow, room.ID)
+	if err != nil {
+		t.Fatalf("subscribe: %v", err)
+	}
+
+	// the reader keeps up with every post, the slow client only piles them up
+	for k := 0; k < receiveBuffer+10; k++ {
+		posted := make(chan error, 1)
+		go func() {
+			posted <- room.post(&message{Type: messageTypeText, Username: "reader", Msg: fmt.Sprintf("message %d", k)})
+		}()
+		select {
+		case err := <-posted:
+			if err != nil {
+				t.Fatalf("post: %v", err)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("room stalled on message %d", k)
+		}
+
+		_, err := readType(conn, messageTypeText)
+		if err != nil {
+			t.Fatalf("read message %d: %v", k, err)
+		}
+	}
+
+	select {
+	case <-slow.done:
+	default:
+		t.Fatal("the client that fell behind is still connected")
+	}
+}
+
+func TestClosedClientDoesNotKeepRoomRunning(t *testing.T) {
+	h, _, _ := newTestHub(t, 20*time.Millisecond)
+
+	room, err := h.createRoom(context.Background(), "idle", "owner", "")
+	if err != nil {
+		t.Fatalf("create room: %v", err)
+	}
+
+	// like a socket closing between the upgrade and the subscription of the roomId param
+	c := newClient(h, nil, &auth.Payload{Username: "gone", ExpiredAt: time.Now().Add(time.Hour)})
+	c.close(websocket.StatusNormalClosure, "")
+	err = h.subscribe(context.Background(), c, room.ID)
+	if err == nil {
+		t.Fatal("subscribing a closed client succeeded")
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		room.mu.Lock()
+		state := room.state
+		room.mu.Unlock()
+		if state == roomSuspended {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("room kept running for a closed client")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestIdleRoomsSuspendAndResume(t *testing.T) {
+	h, b, url := newTestHub(t, 20*time.Millisecond)
+
+	room, err := h.createRoom(context.Background(), "idle", "owner", "")
+	if err != nil {
+		t.Fatalf("create room: %v", err)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 5; j++ {
+				conn := dial(t, url, fmt.Sprintf("user%d", i), room.ID)
+				if conn == nil {
+					return
+				}
+				conn.Close(websocket.StatusNormalClosure, "")
+				// long enough for the room to go idle now and then
+				time.Sleep(time.Duration(j*10) * time.Millisecond)
+			}
+		}()
+		// replies to a suspended room are dropped, they must never block
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 20; j++ {
+				b.dispatch(&botMessage{RoomId: room.ID.String(), Msg: "reply"})
+				time.Sleep(5 * time.Millisecond)
+			}
+		}()
+	}
+	wg.Wait()
+
+	conn := dial(t, url, "last", room.ID)
+	if conn == nil {
+		t.FailNow()
+	}
+	// make sure the resumed room still routes replies
+	for {
+		b.dispatch(&botMessage{RoomId: room.ID.String(), Msg: "hello"})
+		m, err := read(conn)
+		if err != nil {
+			t.Fatalf("read reply: %v", err)
+		}
+		if m.Username == "BOT" && m.Msg == "hello" {
+			return
+		}
+	}
+}
+
+func TestDisconnectRevokedTokens(t *testing.T) {
+	h, b, url := newTestHub(t, 0)
+
+	room, err := h.createRoom(context.Background(), "revoked", "owner", "")
+	if err != nil {
+		t.Fatalf("create room: %v", err)
+	}
+
+	revoked, kept := uuid.New(), uuid.New()
+	revokedConn := dialToken(t, url, "user", revoked, room.ID)
+	keptConn := dialToken(t, url, "user", kept, room.ID)
+	if revokedConn == nil || keptConn == nil {
+		t.FailNow()
+	}
+
+	h.DisconnectTokens([]uuid.UUID{revoked})
+
+	_, err = read(revokedConn)
+	if websocket.CloseStatus(err) != websocket.StatusPolicyViolation {
+		t.Fatalf("revoked connection: got %v, want policy violation", err)
+	}
+
+	b.dispatch(&botMessage{RoomId: room.ID.String(), Msg: "still here"})
+	m, err := read(keptConn)
+	if err != nil || m.Msg != "still here" {
+		t.Fatalf("kept connection: got %+v, %v", m, err)
+	}
+
+	h.DisconnectUser("user")
+	for {
+		_, err = read(keptConn)
+		if err != nil {
+			break
+		}
+	}
+	if websocket.CloseStatus(err) != websocket.StatusPolicyViolation {
+		t.Fatalf("user connection: got %v, want policy violation", err)
+	}
+}
+
+func TestExpiredTokenClosesConnection(t *testing.T) {
+	_, _, url := newTestHub(t, 0)
+
+	conn := connect(t, url, "u=user&ttl=500ms")
+	if conn == nil {
+		t.FailNow()
+	}
+
+	_, err := readType(conn, messageTypeReauthRequired)
+	if err != nil {
+		t.Fatalf("want reauth_required: %v", err)
+	}
+
+	_, err = read(conn)
+	if websocket.CloseStatus(err) != websocket.StatusPolicyViolation {
+		t.Fatalf("got %v, want policy violation", err)
+	}
+}
+
+func TestReauthKeepsConnectionOpen(t *testing.T) {
+	h, _, url := newTestHub(t, 0)
+
+	room, err := h.createRoom(context.Background(), "reauth", "owner", "")
+	if err != nil {
+		t.Fatalf("create room: %v", err)
+	}
+
+	conn := connect(t, url, "u=user&ttl=500ms")
+	if conn == nil {
+		t.FailNow()
+	}
+
+	_, err = readType(conn, messageTypeReauthRequired)
+	if err != nil {
+		t.Fatalf("want reauth_required: %v", err)
+	}
+
+	ctx := context.Background()
+	other, _, err := testTokens.CreateToken("other", time.Hour)
+	if err != nil {
+		t.Fatalf("create token: %v", err)
+	}
+	err = wsjson.Write(ctx, conn, message{Type: messageTypeReauth, Token: other})
+	if err != nil {
+		t.Fatalf("write reauth: %v", err)
+	}
+	m, err := read(conn)
+	if err != nil || m.Code != errCodeInvalidToken {
+		t.Fatalf("got %+v, %v, want invalid_token", m, err)
+	}
+
+	fresh, _, err := testTokens.CreateToken("user", time.Hour)
+	if err != nil {
+		t.Fatalf("create token: %v", err)
+	}
+	err = wsjson.Write(ctx, conn, message{Type: messageTypeReauth, Token: fresh})
+	if err != nil {
+		t.Fatalf("write reauth: %v", err)
+	}
+	m, err = read(conn)
+	if err != nil || m.Type != messageTypeReauthenticated {
+		t.Fatalf("got %+v, %v, want reauthenticated", m, err)
+	}
+
+	// past the expiry of the first token the connection still works
+	time.Sleep(time.Second)
+	err = wsjson.Write(ctx, conn, message{Type: messageTypeSubscribe, RoomId: room.ID.String()})
+	if err != nil {
+		t.Fatalf("write subscribe: %v", err)
+	}
+	m, err = read(conn)
+	if err != nil || m.Type != messageTypeSubscribed {
+		t.Fatalf("got %+v, %v, want subscribed", m, err)
+	}
+}
+
+func TestTicketsAreSingleUse(t *testing.T) {
+	h, _, url := newTestHub(t, 0)
+
+	value, _, err := h.tickets.issue(&auth.Payload{Username: "user", ExpiredAt: time.Now().Add(time.Hour)})
+	if err != nil {
+		t.Fatalf("issue ticket: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// browsers pass the ticket as a subprotocol
+	conn, _, err := websocket.Dial(ctx, url, &websocket.DialOptions{Subprotocols: []string{"chat", ticketProtocolPrefix + value}})
+	if err != nil {
+		t.Fatalf("dial with ticket: %v", err)
+	}
+	defer conn.Close(websocket.StatusNormalClosure, "")
+	if conn.Subprotocol() != "chat" {
+		t.Fatalf("got subprotocol %q, want chat", conn.Subprotocol())
+	}
+
+	_, _, err = websocket.Dial(ctx, url+"?"+ticketParam+"="+value, nil)
+	if err == nil {
+		t.Fatal("dial with a used ticket succeeded")
+	}
+}
+
+func TestTicketOnlySubprotocol(t *testing.T) {
+	h, _, url := newTestHub(t, 0)
+
+	value, _, err := h.tickets.issue(&auth.Payload{Username: "user", ExpiredAt: time.Now().Add(time.Hour)})
+	if err != nil {
+		t.Fatalf("issue ticket: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// browsers fail the handshake unless the server picks one of the offered subprotocols
+	protocol := ticketProtocolPrefix + value
+	conn, _, err := websocket.Dial(ctx, url, &websocket.DialOptions{Subprotocols: []string{protocol}})
+	if err != nil {
+		t.Fatalf("dial with ticket: %v", err)
+	}
+	defer conn.Close(websocket.StatusNormalClosure, "")
+	if conn.Subprotocol() != protocol {
+		t.Fatalf("got subprotocol %q, want %q", conn.Subprotocol(), protocol)
+	}
+}
+
+func TestTicketsFollowRevocations(t *testing.T) {
+	h, _, url := newTestHub(t, 0)
+
+	tokenId := uuid.New()
+	value, _, err := h.tickets.issue(&auth.Payload{ID: tokenId, Username: "user", ExpiredAt: time.Now().Add(time.Hour)})
+	if err != nil {
+		t.Fatalf("issue ticket: %v", err)
+	}
+
+	h.DisconnectTokens([]uuid.UUID{tokenId})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	_, _, err = websocket.Dial(ctx, url+"?"+ticketParam+"="+value, nil)
+	if err == nil {
+		t.Fatal("dial with the ticket of a revoked token succeeded")
+	}
+}
+
+func TestForeignOriginsAreRejected(t *testing.T) {
+	_, _, url := newTestHub(t, 0)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	header := http.Header{}
+	header.Set("Origin", "https://evil.example.com")
+	_, _, err := websocket.Dial(ctx, url+"?u=user", &websocket.DialOptions{HTTPHeader: header})
+	if err == nil {
+		t.Fatal("dial from a foreign origin succeeded")
+	}
+}
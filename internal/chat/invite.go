@@ -0,0 +1,140 @@
+package chat
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	visibilityPublic  = "public"
+	visibilityPrivate = "private"
+)
+
+var (
+	errInviteNotFound = errors.New("invite doesn't exist")
+	errUserNotFound   = errors.New("user doesn't exist")
+	errInvalidRoom    = errors.New("invalid room")
+	errInvalidInvite  = errors.New("invalid invite")
+)
+
+// invite lets Username become a member of a private room once accepted
+type invite struct {
+	ID        int64     `json:"id"`
+	RoomID    uuid.UUID `json:"roomId"`
+	Username  string    `json:"username"`
+	InvitedBy string    `json:"invitedBy"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+func validVisibility(visibility string) (string, error) {
+	switch visibility {
+	case "":
+		return visibilityPublic, nil
+	case visibilityPublic, visibilityPrivate:
+		return visibility, nil
+	}
+	return "", fmt.Errorf("%w: visibility must be %s or %s", errInvalidRoom, visibilityPublic, visibilityPrivate)
+}
+
+// checkAccess fails when the room is private and username isn't one of its members
+func (h *hub) checkAccess(ctx context.Context, room *room, username string) error {
+	if room.Visibility != visibilityPrivate || room.Owner == username {
+		return nil
+	}
+
+	role, err := h.repo.GetRole(ctx, room.ID, username)
+	if err != nil {
+		return err
+	}
+	if role == "" {
+		return fmt.Errorf("%w: this room is private, you need an invite", errForbidden)
+	}
+	return nil
+}
+
+// CanAccess reports whether username can see what goes on in the room, like its schedules
+func (h *hub) CanAccess(ctx context.Context, roomId uuid.UUID, username string) (bool, error) {
+	room, err := h.getRoom(roomId)
+	if err != nil {
+		return false, err
+	}
+
+	err = h.checkAccess(ctx, room, username)
+	if errors.Is(err, errForbidden) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// Invite lets target join a private room, owners and moderators can invite
+func (h *hub) Invite(ctx context.Context, actor string, roomId uuid.UUID, target string) (invite, error) {
+	room, err := h.getRoom(roomId)
+	if err != nil {
+		return invite{}, err
+	}
+	if room.Visibility != visibilityPrivate {
+		return invite{}, fmt.Errorf("%w: public rooms don't need invites", errInvalidInvite)
+	}
+	if target == "" {
+		return invite{}, fmt.Errorf("%w: username required", errInvalidInvite)
+	}
+
+	role, err := h.role(ctx, room, actor)
+	if err != nil {
+		return invite{}, err
+	}
+	if role != roleOwner && role != roleModerator {
+		return invite{}, fmt.Errorf("%w: only owners and moderators can invite", errForbidden)
+	}
+
+	targetRole, err := h.repo.GetRole(ctx, roomId, target)
+	if err != nil {
+		return invite{}, err
+	}
+	if targetRole != "" || target == room.Owner {
+		return invite{}, fmt.Errorf("%w: %s is already a member", errInvalidInvite, target)
+	}
+
+	return h.repo.CreateInvite(ctx, invite{RoomID: roomId, Username: target, InvitedBy: actor})
+}
+
+// Invites lists the pending invites of username
+func (h *hub) Invites(ctx context.Context, username string) ([]invite, error) {
+	return h.repo.UserInvites(ctx, username)
+}
+
+// AcceptInvite makes username a member of the room it was invited to
+func (h *hub) AcceptInvite(ctx context.Context, username string, id int64) (*room, error) {
+	inv, err := h.repo.GetInvite(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	// other users' invites are reported as missing, like unknown ones
+	if inv == nil || inv.Username != username {
+		return nil, errInviteNotFound
+	}
+
+	room, err := h.getRoom(inv.RoomID)
+	if err != nil {
+		return nil, err
+	}
+
+	err = h.repo.AddMember(ctx, inv.RoomID, username, roleMember)
+	if err != nil {
+		return nil, err
+	}
+
+	err = h.repo.DeleteInvite(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return room, nil
+}
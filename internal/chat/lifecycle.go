@@ -0,0 +1,111 @@
+package chat
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+const (
+	archivedCode = "archived"
+	deletedCode  = "deleted"
+)
+
+// roomUpdate holds the room details to change, nil fields are left as they are
+type roomUpdate struct {
+	Title       *string `json:"title"`
+	Description *string `json:"description"`
+	Topic       *string `json:"topic"`
+}
+
+// ownedRoom returns the room when actor is its owner
+func (h *hub) ownedRoom(actor string, roomId uuid.UUID) (*room, error) {
+	room, err := h.getRoom(roomId)
+	if err != nil {
+		return nil, err
+	}
+	if room.Owner != actor {
+		return nil, fmt.Errorf("%w: only the owner can manage the room", errForbidden)
+	}
+	return room, nil
+}
+
+// RoomInfo returns the details of a room, private rooms only to their members
+func (h *hub) RoomInfo(ctx context.Context, username string, roomId uuid.UUID) (roomInfo, error) {
+	room, err := h.getRoom(roomId)
+	if err != nil {
+		return roomInfo{}, err
+	}
+
+	err = h.checkAccess(ctx, room, username)
+	if err != nil {
+		return roomInfo{}, err
+	}
+
+	return room.info(), nil
+}
+
+// UpdateRoom changes the title, description or topic of a room and tells its clients
+func (h *hub) UpdateRoom(ctx context.Context, actor string, roomId uuid.UUID, update roomUpdate) (roomInfo, error) {
+	room, err := h.ownedRoom(actor, roomId)
+	if err != nil {
+		return roomInfo{}, err
+	}
+	if update.Title != nil && strings.TrimSpace(*update.Title) == "" {
+		return roomInfo{}, fmt.Errorf("%w: title can't be empty", errInvalidRoom)
+	}
+
+	var changes []string
+	room.mu.Lock()
+	if update.Title != nil && *update.Title != room.title {
+		room.title = strings.TrimSpace(*update.Title)
+		changes = append(changes, fmt.Sprintf("the title to %q", room.title))
+	}
+	if update.Description != nil && *update.Description != room.description {
+		room.description = strings.TrimSpace(*update.Description)
+		changes = append(changes, "the description")
+	}
+	if update.Topic != nil && *update.Topic != room.topic {
+		room.topic = strings.TrimSpace(*update.Topic)
+		changes = append(changes, fmt.Sprintf("the topic to %q", room.topic))
+	}
+	room.mu.Unlock()
+
+	if len(changes) > 0 {
+		room.announce(fmt.Sprintf("%s changed %s", actor, strings.Join(changes, ", ")))
+	}
+	return room.info(), nil
+}
+
+// ArchiveRoom makes a room read-only: its clients are dropped, nobody can join
+// or post anymore and it stops running, but its details can still be read
+func (h *hub) ArchiveRoom(ctx context.Context, actor string, roomId uuid.UUID) (roomInfo, error) {
+	room, err := h.ownedRoom(actor, roomId)
+	if err != nil {
+		return roomInfo{}, err
+	}
+
+	if room.info().Archived {
+		return room.info(), nil
+	}
+
+	room.close(roomArchived, archivedCode, "room archived by "+actor)
+
+	return room.info(), nil
+}
+
+// DeleteRoom disconnects the clients of a room and forgets about it, along
+// with its members, invites, sanctions, alerts and schedules
+func (h *hub) DeleteRoom(ctx context.Context, actor string, roomId uuid.UUID) error {
+	room, err := h.ownedRoom(actor, roomId)
+	if err != nil {
+		return err
+	}
+
+	room.close(roomDeleted, deletedCode, "room deleted by "+actor)
+	h.removeRoom(room.ID)
+
+	return h.repo.DeleteRoom(ctx, room.ID)
+}
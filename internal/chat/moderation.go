@@ -0,0 +1,348 @@
+package chat
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	roleOwner     = "owner"
+	roleModerator = "moderator"
+	roleMember    = "member"
+
+	sanctionMute = "mute"
+	sanctionBan  = "ban"
+
+	errCodeMuted     = "muted"
+	kickedCode       = "kicked"
+	bannedCode       = "banned"
+	errCodeForbidden = "forbidden"
+	errCodeInvalid   = "invalid_command"
+)
+
+var (
+	errRoomNotFound      = errors.New("room doesn't exist")
+	errForbidden         = errors.New("forbidden")
+	errInvalidModeration = errors.New("invalid moderation request")
+)
+
+// sanction is a mute or ban of a user in a room, without ExpiresAt it is permanent
+type sanction struct {
+	ID        int64      `json:"id"`
+	RoomID    uuid.UUID  `json:"roomId"`
+	Username  string     `json:"username"`
+	Kind      string     `json:"kind"`
+	Reason    string     `json:"reason"`
+	CreatedBy string     `json:"createdBy"`
+	CreatedAt time.Time  `json:"createdAt"`
+	ExpiresAt *time.Time `json:"expiresAt"`
+}
+
+type roomRepo interface {
+	SetRole(ctx context.Context, roomId uuid.UUID, username string, role string) error
+	GetRole(ctx context.Context, roomId uuid.UUID, username string) (string, error)
+	AddSanction(ctx context.Context, s sanction) (sanction, error)
+	ActiveSanction(ctx context.Context, roomId uuid.UUID, username string, kind string) (*sanction, error)
+	RemoveSanctions(ctx context.Context, roomId uuid.UUID, username string, kind string) (bool, error)
+	AddMember(ctx context.Context, roomId uuid.UUID, username string, role string) error
+	CreateInvite(ctx context.Context, inv invite) (invite, error)
+	GetInvite(ctx context.Context, id int64) (*invite, error)
+	UserInvites(ctx context.Context, username string) ([]invite, error)
+	DeleteInvite(ctx context.Context, id int64) error
+	AddConversation(ctx context.Context, roomId uuid.UUID, a string, b string) error
+	Conversations(ctx context.Context, username string) ([]conversation, error)
+	DeleteRoom(ctx context.Context, roomId uuid.UUID) error
+}
+
+// role returns the role of username in the room, member when it has none
+func (h *hub) role(ctx context.Context, room *room, username string) (string, error) {
+	if room.Owner == username {
+		return roleOwner, nil
+	}
+
+	role, err := h.repo.GetRole(ctx, room.ID, username)
+	if err != nil {
+		return "", err
+	}
+	if role == "" {
+		return roleMember, nil
+	}
+	return role, nil
+}
+
+// canModerate checks that actor outranks target: owners moderate everyone,
+// moderators only members
+func (h *hub) canModerate(ctx context.Context, room *room, actor string, target string) error {
+	if actor == target {
+		return fmt.Errorf("%w: you can't moderate yourself", errInvalidModeration)
+	}
+
+	actorRole, err := h.role(ctx, room, actor)
+	if err != nil {
+		return err
+	}
+	targetRole, err := h.role(ctx, room, target)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case actorRole == roleOwner:
+		return nil
+	case actorRole == roleModerator && targetRole == roleMember:
+		return nil
+	}
+	return fmt.Errorf("%w: you can't moderate %s", errForbidden, target)
+}
+
+func (h *hub) moderatedRoom(ctx context.Context, actor string, roomId uuid.UUID, target string) (*room, error) {
+	room, err := h.getRoom(roomId)
+	if err != nil {
+		return nil, errRoomNotFound
+	}
+	if target == "" {
+		return nil, fmt.Errorf("%w: username required", errInvalidModeration)
+	}
+
+	err = h.canModerate(ctx, room, actor, target)
+	if err != nil {
+		return nil, err
+	}
+	return room, nil
+}
+
+// Kick closes every connection of target to the room, it can join again right away
+func (h *hub) Kick(ctx context.Context, actor string, roomId uuid.UUID, target string, reason string) error {
+	room, err := h.moderatedRoom(ctx, actor, roomId, target)
+	if err != nil {
+		return err
+	}
+
+	room.kickUser(&kickReq{username: target, code: kickedCode, reason: withReason("kicked by "+actor, reason)})
+	room.announce(withReason(fmt.Sprintf("%s was kicked by %s", target, actor), reason))
+	return nil
+}
+
+// Mute drops the messages of target in the room for duration
+func (h *hub) Mute(ctx context.Context, actor string, roomId uuid.UUID, target string, duration time.Duration, reason string) (sanction, error) {
+	room, err := h.moderatedRoom(ctx, actor, roomId, target)
+	if err != nil {
+		return sanction{}, err
+	}
+	if duration <= 0 {
+		return sanction{}, fmt.Errorf("%w: mute duration must be positive", errInvalidModeration)
+	}
+
+	expiresAt := time.Now().Add(duration)
+	s, err := h.repo.AddSanction(ctx, sanction{
+		RoomID:    roomId,
+		Username:  target,
+		Kind:      sanctionMute,
+		Reason:    reason,
+		CreatedBy: actor,
+		ExpiresAt: &expiresAt,
+	})
+	if err != nil {
+		return sanction{}, err
+	}
+
+	room.setMute(target, expiresAt)
+	room.announce(withReason(fmt.Sprintf("%s was muted for %s by %s", target, duration, actor), reason))
+	return s, nil
+}
+
+func (h *hub) Unmute(ctx context.Context, actor string, roomId uuid.UUID, target string) error {
+	room, err := h.moderatedRoom(ctx, actor, roomId, target)
+	if err != nil {
+		return err
+	}
+
+	_, err = h.repo.RemoveSanctions(ctx, roomId, target, sanctionMute)
+	if err != nil {
+		return err
+	}
+
+	room.clearMute(target)
+	room.announce(fmt.Sprintf("%s was unmuted by %s", target, actor))
+	return nil
+}
+
+// Ban kicks target and keeps it from joining the room again, for duration or forever when it is 0
+func (h *hub) Ban(ctx context.Context, actor string, roomId uuid.UUID, target string, duration time.Duration, reason string) (sanction, error) {
+	room, err := h.moderatedRoom(ctx, actor, roomId, target)
+	if err != nil {
+		return sanction{}, err
+	}
+	if duration < 0 {
+		return sanction{}, fmt.Errorf("%w: ban duration can't be negative", errInvalidModeration)
+	}
+
+	s := sanction{
+		RoomID:    roomId,
+		Username:  target,
+		Kind:      sanctionBan,
+		Reason:    reason,
+		CreatedBy: actor,
+	}
+	banned := fmt.Sprintf("%s was banned by %s", target, actor)
+	if duration > 0 {
+		expiresAt := time.Now().Add(duration)
+		s.ExpiresAt = &expiresAt
+		banned = fmt.Sprintf("%s was banned for %s by %s", target, duration, actor)
+	}
+	s, err = h.repo.AddSanction(ctx, s)
+	if err != nil {
+		return sanction{}, err
+	}
+
+	room.kickUser(&kickReq{username: target, code: bannedCode, reason: withReason("banned by "+actor, reason)})
+	room.announce(withReason(banned, reason))
+	return s, nil
+}
+
+func (h *hub) Unban(ctx context.Context, actor string, roomId uuid.UUID, target string) error {
+	room, err := h.moderatedRoom(ctx, actor, roomId, target)
+	if err != nil {
+		return err
+	}
+
+	_, err = h.repo.RemoveSanctions(ctx, roomId, target, sanctionBan)
+	if err != nil {
+		return err
+	}
+
+	room.announce(fmt.Sprintf("%s was unbanned by %s", target, actor))
+	return nil
+}
+
+// SetModerator grants or revokes the moderator role of target, only the owner can do it
+func (h *hub) SetModerator(ctx context.Context, actor string, roomId uuid.UUID, target string, moderator bool) error {
+	room, err := h.getRoom(roomId)
+	if err != nil {
+		return errRoomNotFound
+	}
+	if actor != room.Owner {
+		return fmt.Errorf("%w: only the owner can manage moderators", errForbidden)
+	}
+	if target == "" || target == room.Owner {
+		return fmt.Errorf("%w: invalid username", errInvalidModeration)
+	}
+
+	role, msg := roleMember, fmt.Sprintf("%s is no longer a moderator", target)
+	if moderator {
+		role, msg = roleModerator, fmt.Sprintf("%s is now a moderator", target)
+	}
+	err = h.repo.SetRole(ctx, roomId, target, role)
+	if err != nil {
+		return err
+	}
+
+	room.announce(msg)
+	return nil
+}
+
+// checkBan fails when username has an active ban in the room
+func (h *hub) checkBan(ctx context.Context, roomId uuid.UUID, username string) error {
+	ban, err := h.repo.ActiveSanction(ctx, roomId, username, sanctionBan)
+	if err != nil {
+		return err
+	}
+	if ban == nil {
+		return nil
+	}
+
+	if ban.ExpiresAt != nil {
+		return fmt.Errorf("%w: banned from this room until %s", errForbidden, ban.ExpiresAt.Format(time.RFC3339))
+	}
+	return fmt.Errorf("%w: banned from this room", errForbidden)
+}
+
+// loadMute restores a mute of username in the room, so it survives reconnects and restarts
+func (h *hub) loadMute(ctx context.Context, room *room, username string) error {
+	mute, err := h.repo.ActiveSanction(ctx, room.ID, username, sanctionMute)
+	if err != nil || mute == nil || mute.ExpiresAt == nil {
+		return err
+	}
+
+	room.setMute(username, *mute.ExpiresAt)
+	return nil
+}
+
+// moderate runs a moderation command sent over the websocket, like
+// /mute bob 10m spamming. It reports false for messages that aren't one
+func (c *client) moderate(ctx context.Context, room *room, msg string) bool {
+	if !strings.HasPrefix(msg, "/") {
+		return false
+	}
+	fields := strings.Fields(strings.TrimPrefix(msg, "/"))
+	if len(fields) == 0 {
+		return false
+	}
+
+	name, args := strings.ToLower(fields[0]), fields[1:]
+	target, rest := "", ""
+	if len(args) > 0 {
+		target, rest = args[0], strings.Join(args[1:], " ")
+	}
+
+	h, roomId := c.hub, room.ID
+	var err error
+	switch name {
+	case "kick":
+		err = h.Kick(ctx, c.username, roomId, target, rest)
+	case "mute":
+		durationStr, reason, _ := strings.Cut(rest, " ")
+		var duration time.Duration
+		duration, err = time.ParseDuration(durationStr)
+		if err != nil {
+			err = fmt.Errorf("%w: usage /mute <username> <duration, like 10m> [reason]", errInvalidModeration)
+			break
+		}
+		_, err = h.Mute(ctx, c.username, roomId, target, duration, reason)
+	case "unmute":
+		err = h.Unmute(ctx, c.username, roomId, target)
+	case "ban":
+		// the duration is optional, without one the ban is permanent
+		durationStr, reason, _ := strings.Cut(rest, " ")
+		duration, parseErr := time.ParseDuration(durationStr)
+		if parseErr != nil {
+			duration, reason = 0, rest
+		}
+		_, err = h.Ban(ctx, c.username, roomId, target, duration, reason)
+	case "unban":
+		err = h.Unban(ctx, c.username, roomId, target)
+	case "mod":
+		err = h.SetModerator(ctx, c.username, roomId, target, true)
+	case "unmod":
+		err = h.SetModerator(ctx, c.username, roomId, target, false)
+	default:
+		return false
+	}
+
+	switch {
+	case errors.Is(err, errForbidden):
+		c.sendError(ctx, roomId.String(), errCodeForbidden, err.Error())
+	case err != nil:
+		c.sendError(ctx, roomId.String(), errCodeInvalid, err.Error())
+	}
+	return true
+}
+
+func withReason(msg string, reason string) string {
+	if reason == "" {
+		return msg
+	}
+	return msg + ": " + reason
+}
+
+// kickReq drops every connection of username from a room
+type kickReq struct {
+	username string
+	code     string
+	reason   string
+}
@@ -0,0 +1,114 @@
+package chat
+
+import (
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	errCodeRateLimited = "rate_limited"
+	// buckets untouched for this long are full again, so they can be forgotten
+	bucketIdleTTL = 10 * time.Minute
+)
+
+// rateLimit allows perMinute events on average, with bursts of up to burst events
+type rateLimit struct {
+	perMinute int
+	burst     int
+}
+
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// take refills the bucket for the time elapsed since the last call and
+// takes a token from it when there is one
+func (b *tokenBucket) take(limit rateLimit, now time.Time) bool {
+	elapsed := now.Sub(b.last).Minutes()
+	b.tokens = min(b.tokens+elapsed*float64(limit.perMinute), float64(limit.burst))
+	b.last = now
+
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+type bucketKey struct {
+	kind string
+	id   string
+}
+
+// limiter keeps token buckets for the messages and bot commands of every
+// user, plus the bot commands of every room, shared by all connections
+type limiter struct {
+	mu           sync.Mutex
+	messages     rateLimit
+	commands     rateLimit
+	roomCommands rateLimit
+	buckets      map[bucketKey]*tokenBucket
+	lastSweep    time.Time
+}
+
+func newLimiter(messages, commands, roomCommands rateLimit) *limiter {
+	return &limiter{
+		messages:     messages,
+		commands:     commands,
+		roomCommands: roomCommands,
+		buckets:      make(map[bucketKey]*tokenBucket),
+		lastSweep:    time.Now(),
+	}
+}
+
+// allow reports whether username may send msg to the room, and why not when it can't
+func (l *limiter) allow(username string, roomId uuid.UUID, msg string) (bool, string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	l.sweep(now)
+
+	if isCommand(msg) {
+		if !l.take(bucketKey{"command", username}, l.commands, now) {
+			return false, "too many bot commands, slow down"
+		}
+		if !l.take(bucketKey{"room", roomId.String()}, l.roomCommands, now) {
+			return false, "too many bot commands in this room, try again later"
+		}
+		return true, ""
+	}
+
+	if !l.take(bucketKey{"message", username}, l.messages, now) {
+		return false, "too many messages, slow down"
+	}
+	return true, ""
+}
+
+func (l *limiter) take(key bucketKey, limit rateLimit, now time.Time) bool {
+	if limit.perMinute <= 0 {
+		return true
+	}
+
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &tokenBucket{tokens: float64(limit.burst), last: now}
+		l.buckets[key] = b
+	}
+	return b.take(limit, now)
+}
+
+func (l *limiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < bucketIdleTTL {
+		return
+	}
+	for key, b := range l.buckets {
+		if now.Sub(b.last) > bucketIdleTTL {
+			delete(l.buckets, key)
+		}
+	}
+	l.lastSweep = now
+}
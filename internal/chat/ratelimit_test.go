@@ -0,0 +1,76 @@
+package chat
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestTokenBucket(t *testing.T) {
+	limit := rateLimit{perMinute: 60, burst: 3}
+	start := time.Date(2026, 10, 19, 10, 0, 0, 0, time.UTC)
+	b := &tokenBucket{tokens: float64(limit.burst), last: start}
+
+	tests := []struct {
+		after time.Duration
+		want  bool
+	}{
+		// the burst goes through at once
+		{after: 0, want: true},
+		{after: 0, want: true},
+		{after: 0, want: true},
+		{after: 0, want: false},
+		// one token a second comes back
+		{after: 500 * time.Millisecond, want: false},
+		{after: time.Second, want: true},
+		{after: time.Second, want: false},
+		// and never more than the burst
+		{after: time.Hour, want: true},
+		{after: time.Hour, want: true},
+		{after: time.Hour, want: true},
+		{after: time.Hour, want: false},
+	}
+
+	for i, tt := range tests {
+		if got := b.take(limit, start.Add(tt.after)); got != tt.want {
+			t.Errorf("take %d after %s = %v, want %v", i, tt.after, got, tt.want)
+		}
+	}
+}
+
+func TestLimiterKeepsMessagesAndCommandsApart(t *testing.T) {
+	l := newLimiter(rateLimit{perMinute: 1, burst: 1}, rateLimit{perMinute: 1, burst: 1}, rateLimit{perMinute: 1, burst: 2})
+	room := uuid.New()
+
+	tests := []struct {
+		username string
+		msg      string
+		want     bool
+	}{
+		{username: "a", msg: "hello", want: true},
+		{username: "a", msg: "hello again", want: false},
+		// commands have their own bucket
+		{username: "a", msg: "/stock=aapl.us", want: true},
+		{username: "a", msg: "/stock=aapl.us", want: false},
+		// other users have theirs, up to the room's share
+		{username: "b", msg: "/stock=aapl.us", want: true},
+		{username: "c", msg: "/stock=aapl.us", want: false},
+		{username: "c", msg: "hello", want: true},
+	}
+
+	for _, tt := range tests {
+		if got, reason := l.allow(tt.username, room, tt.msg); got != tt.want {
+			t.Errorf("allow(%s, %q) = %v %q, want %v", tt.username, tt.msg, got, reason, tt.want)
+		}
+	}
+}
+
+func TestLimiterWithoutLimit(t *testing.T) {
+	l := newLimiter(rateLimit{}, rateLimit{}, rateLimit{})
+	for i := 0; i < 100; i++ {
+		if ok, reason := l.allow("a", uuid.Nil, "hello"); !ok {
+			t.Fatalf("message %d rejected: %s", i, reason)
+		}
+	}
+}
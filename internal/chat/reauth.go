@@ -0,0 +1,98 @@
+package chat
+
+import (
+	"context"
+	"financial-chat-api/util/auth"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"nhooyr.io/websocket"
+)
+
+const (
+	// the server sends reauth_required ahead of the token expiry, the client answers
+	// with a reauth event carrying a fresh token, acknowledged with reauthenticated
+	messageTypeReauth          = "reauth"
+	messageTypeReauthRequired  = "reauth_required"
+	messageTypeReauthenticated = "reauthenticated"
+
+	errCodeInvalidToken = "invalid_token"
+
+	// reauthNotice is how long before the token expires the client is asked for a new one
+	reauthNotice = time.Minute
+)
+
+// tokenVerifier checks the tokens sent over an open socket, like auth.Middleware does for requests
+type tokenVerifier interface {
+	Verify(ctx context.Context, token string) (*auth.Payload, error)
+}
+
+// token returns the id and expiry of the token the connection is authorized with
+func (c *client) token() (uuid.UUID, time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.tokenId, c.expiresAt
+}
+
+// watchExpiry asks for a new token shortly before the current one expires, and
+// closes the connection if none arrives in time
+func (c *client) watchExpiry() {
+	for {
+		_, expiresAt := c.token()
+
+		notice := time.NewTimer(time.Until(expiresAt.Add(-c.hub.reauthNotice)))
+		select {
+		case <-notice.C:
+		case <-c.reauthed:
+			notice.Stop()
+			continue
+		case <-c.done:
+			notice.Stop()
+			return
+		}
+
+		c.deliver(&message{
+			Type: messageTypeReauthRequired,
+			Msg:  "token expires at " + expiresAt.Format(time.RFC3339) + ", send a reauth event with a new token"})
+
+		expiry := time.NewTimer(time.Until(expiresAt))
+		select {
+		case <-expiry.C:
+			c.close(websocket.StatusPolicyViolation, "token expired")
+			return
+		case <-c.reauthed:
+			expiry.Stop()
+		case <-c.done:
+			expiry.Stop()
+			return
+		}
+	}
+}
+
+// reauth moves the connection to a fresh token of the same user, a bad token
+// leaves the current one in place until it expires
+func (c *client) reauth(ctx context.Context, token string) {
+	payload, err := c.hub.verifier.Verify(ctx, token)
+	if err != nil {
+		c.sendError(ctx, "", errCodeInvalidToken, err.Error())
+		return
+	}
+	if payload.Username != c.username {
+		c.sendError(ctx, "", errCodeInvalidToken, "token belongs to another user")
+		return
+	}
+
+	c.mu.Lock()
+	c.tokenId, c.expiresAt = payload.ID, payload.ExpiredAt
+	c.mu.Unlock()
+
+	// the watcher may be busy delivering reauth_required, it finds the signal when done
+	select {
+	case c.reauthed <- struct{}{}:
+	default:
+	}
+
+	log.Printf("connection of %s reauthenticated until %s", c.username, payload.ExpiredAt.Format(time.RFC3339))
+	c.deliver(&message{Type: messageTypeReauthenticated, Msg: "token expires at " + payload.ExpiredAt.Format(time.RFC3339)})
+}
@@ -1,56 +1,368 @@
 package chat
 
 import (
+	"errors"
+	"expvar"
 	"log"
+	"sync"
+	"time"
 
 	"github.com/google/uuid"
+	"nhooyr.io/websocket"
 )
 
+// systemUsername signs the notices the room sends about itself, like moderation actions
+const systemUsername = "SYSTEM"
+
+var errRoomArchived = errors.New("room is archived")
+
+var (
+	roomsActive    = expvar.NewInt("rooms_active")
+	roomsSuspended = expvar.NewInt("rooms_suspended")
+)
+
+type roomState int
+
+const (
+	roomRunning roomState = iota
+	// suspended rooms had no clients for a while, their goroutine stopped until someone joins
+	roomSuspended
+	roomArchived
+	roomDeleted
+)
+
+// counter returns the metric that counts rooms in state, if any
+func (s roomState) counter() *expvar.Int {
+	switch s {
+	case roomRunning:
+		return roomsActive
+	case roomSuspended:
+		return roomsSuspended
+	}
+	return nil
+}
+
 type room struct {
-	ID        uuid.UUID `json:"id"`
-	Title     string    `json:"title"`
-	clients   map[*client]struct{}
-	join      chan *client
-	leave     chan *client
-	broadcast chan *message
-	bot       *bot
+	ID          uuid.UUID
+	Owner       string
+	Visibility  string
+	clients     map[*client]struct{}
+	join        chan *client
+	leave       chan *client
+	kick        chan *kickReq
+	broadcast   chan *message
+	notice      chan *message
+	stop        chan *stopReq
+	replies     chan *botMessage
+	bot         *bot
+	idleTimeout time.Duration
+
+	mu sync.Mutex
+	// done is closed once run returns, so nobody waits on a room that stopped.
+	// A new one is made every time the room is resumed
+	done        chan struct{}
+	state       roomState
+	title       string
+	description string
+	topic       string
+	// mutes holds until when each muted user can't talk, loaded from the db on join
+	mutes map[string]time.Time
 }
 
-func newRoom(title string, bot *bot) (*room, error) {
-	uuid, err := uuid.NewRandom()
-	if err != nil {
-		return nil, err
-	}
+// roomInfo is what the api tells about a room
+type roomInfo struct {
+	ID          uuid.UUID `json:"id"`
+	Title       string    `json:"title"`
+	Description string    `json:"description"`
+	Topic       string    `json:"topic"`
+	Owner       string    `json:"owner"`
+	Visibility  string    `json:"visibility"`
+	Archived    bool      `json:"archived"`
+}
+
+// stopReq drops every client of a room and stops it
+type stopReq struct {
+	code   string
+	reason string
+}
+
+// newRoom returns a suspended room, it only starts running once someone joins
+func newRoom(id uuid.UUID, title string, owner string, visibility string, bot *bot, idleTimeout time.Duration) *room {
+	done := make(chan struct{})
+	close(done)
+	roomsSuspended.Add(1)
 
 	return &room{
-		ID:        uuid,
-		Title:     title,
-		clients:   make(map[*client]struct{}),
-		join:      make(chan *client),
-		leave:     make(chan *client),
-		broadcast: make(chan *message),
-		bot:       bot,
-	}, nil
+		ID:          id,
+		Owner:       owner,
+		Visibility:  visibility,
+		clients:     make(map[*client]struct{}),
+		join:        make(chan *client),
+		leave:       make(chan *client),
+		kick:        make(chan *kickReq),
+		broadcast:   make(chan *message),
+		notice:      make(chan *message),
+		stop:        make(chan *stopReq),
+		replies:     make(chan *botMessage, repliesBuffer),
+		bot:         bot,
+		idleTimeout: idleTimeout,
+		done:        done,
+		state:       roomSuspended,
+		title:       title,
+		mutes:       make(map[string]time.Time),
+	}
+}
+
+// setState moves the room to state, keeping the metrics up to date. r.mu must be held
+func (r *room) setState(state roomState) {
+	if c := r.state.counter(); c != nil {
+		c.Add(-1)
+	}
+	if c := state.counter(); c != nil {
+		c.Add(1)
+	}
+	r.state = state
 }
 
-func (r *room) run() {
+// start runs a new or suspended room, it is a no-op for rooms already running or gone
+func (r *room) start() {
+	r.mu.Lock()
+	if r.state != roomSuspended {
+		r.mu.Unlock()
+		return
+	}
+	r.setState(roomRunning)
+	r.done = make(chan struct{})
+	done := r.done
+	r.mu.Unlock()
+
+	r.bot.register(r.ID, r.replies, done)
+	go r.run(done)
+}
+
+// running returns the done channel of the room, resuming it when it was suspended
+func (r *room) running() (chan struct{}, error) {
+	r.start()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.state == roomArchived || r.state == roomDeleted {
+		return nil, errRoomArchived
+	}
+	return r.done, nil
+}
+
+func (r *room) run(done chan struct{}) {
 	log.Printf("room %s running...\n", r.ID)
+	defer close(done)
+
+	// idle fires once the room went idleTimeout without clients
+	var idle *time.Timer
+	resetIdle := func() {
+		if idle != nil {
+			idle.Stop()
+			idle = nil
+		}
+		if len(r.clients) == 0 && r.idleTimeout > 0 {
+			idle = time.NewTimer(r.idleTimeout)
+		}
+	}
+	idleC := func() <-chan time.Time {
+		if idle == nil {
+			return nil
+		}
+		return idle.C
+	}
+	resetIdle()
+	// only the timer, a resumed run may already own the clients
+	defer func() {
+		if idle != nil {
+			idle.Stop()
+		}
+	}()
+
 	for {
 		select {
 		case client := <-r.join:
 			r.clients[client] = struct{}{}
+			resetIdle()
 		case client := <-r.leave:
 			delete(r.clients, client)
-			close(client.receive)
-		case msg := <-r.broadcast:
-			r.bot.sendCh <- &botMessage{RoomId: r.ID.String(), Msg: msg.Msg}
+			resetIdle()
+		case k := <-r.kick:
 			for c := range r.clients {
-				c.receive <- msg
+				if c.username != k.username {
+					continue
+				}
+				delete(r.clients, c)
+				c.removed(r, websocket.StatusPolicyViolation, k.code, k.reason)
 			}
-		case msg := <-r.bot.roomReceiveCh[r.ID]:
+			resetIdle()
+		case s := <-r.stop:
+			r.bot.unregister(r.ID)
 			for c := range r.clients {
-				c.receive <- &message{Username: "BOT", Msg: msg.Msg}
+				delete(r.clients, c)
+				c.removed(r, websocket.StatusGoingAway, s.code, s.reason)
+			}
+			log.Printf("room %s stopped: %s\n", r.ID, s.reason)
+			return
+		case <-idleC():
+			r.suspend()
+			return
+		case msg := <-r.broadcast:
+			if isCommand(msg.Msg) {
+				r.bot.enqueue(&botMessage{RoomId: r.ID.String(), Username: msg.Username, Msg: msg.Msg})
+			}
+			r.deliver(msg)
+		case msg := <-r.notice:
+			r.deliver(msg)
+		case msg := <-r.replies:
+			botMsg := &message{Type: messageTypeText, RoomId: r.ID.String(), Username: "BOT", Msg: msg.Msg, Data: msg.Data}
+			if msg.Attachment != nil {
+				botMsg.Type = messageTypeAttachment
+				botMsg.Attachment = msg.Attachment
 			}
+			r.deliver(botMsg)
 		}
 	}
 }
+
+// suspend unregisters an idle room from the bot, so run can return until the next join
+func (r *room) suspend() {
+	// before the state changes, so it can't undo the registration of a resumed run
+	r.bot.unregister(r.ID)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.state == roomRunning {
+		r.setState(roomSuspended)
+	}
+	log.Printf("room %s suspended after %s idle\n", r.ID, r.idleTimeout)
+}
+
+func (r *room) deliver(msg *message) {
+	for c := range r.clients {
+		c.deliver(msg)
+	}
+}
+
+// The methods below hand work to run. Joins and posts resume a suspended room,
+// everything else gives up once the room stopped
+
+func (r *room) addClient(c *client) error {
+	for {
+		done, err := r.running()
+		if err != nil {
+			return err
+		}
+
+		select {
+		case r.join <- c:
+			return nil
+		case <-done:
+			// suspended meanwhile, try again
+		}
+	}
+}
+
+func (r *room) post(msg *message) error {
+	for {
+		done, err := r.running()
+		if err != nil {
+			return err
+		}
+
+		select {
+		case r.broadcast <- msg:
+			return nil
+		case <-done:
+		}
+	}
+}
+
+// stopped returns a channel closed while the room isn't running
+func (r *room) stopped() chan struct{} {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.done
+}
+
+func (r *room) removeClient(c *client) {
+	select {
+	case r.leave <- c:
+	case <-r.stopped():
+	}
+}
+
+func (r *room) kickUser(k *kickReq) {
+	select {
+	case r.kick <- k:
+	case <-r.stopped():
+	}
+}
+
+// announce tells everyone in the room about something that happened in it,
+// without going through the bot
+func (r *room) announce(msg string) {
+	select {
+	case r.notice <- &message{Type: messageTypeText, RoomId: r.ID.String(), Username: systemUsername, Msg: msg}:
+	case <-r.stopped():
+	}
+}
+
+// close disconnects every client with reason and stops the room for good, as state
+func (r *room) close(state roomState, code string, reason string) {
+	r.mu.Lock()
+	r.setState(state)
+	done := r.done
+	r.mu.Unlock()
+
+	select {
+	case r.stop <- &stopReq{code: code, reason: reason}:
+		<-done
+	case <-done:
+	}
+}
+
+func (r *room) info() roomInfo {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return roomInfo{
+		ID:          r.ID,
+		Title:       r.title,
+		Description: r.description,
+		Topic:       r.topic,
+		Owner:       r.Owner,
+		Visibility:  r.Visibility,
+		Archived:    r.state == roomArchived,
+	}
+}
+
+func (r *room) setMute(username string, until time.Time) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.mutes[username] = until
+}
+
+func (r *room) clearMute(username string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.mutes, username)
+}
+
+// mutedUntil reports whether username is muted right now and until when
+func (r *room) mutedUntil(username string) (time.Time, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	until, ok := r.mutes[username]
+	if !ok {
+		return time.Time{}, false
+	}
+	if time.Now().After(until) {
+		delete(r.mutes, username)
+		return time.Time{}, false
+	}
+	return until, true
+}
@@ -0,0 +1,235 @@
+package chat
+
+import (
+	"context"
+	"errors"
+	db "financial-chat-api/db/sqlc"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// foreignKeyViolation is the postgres error code for references to missing rows, like unknown users
+const foreignKeyViolation = "23503"
+
+type repository struct {
+	*db.Queries
+	// pool begins the transactions of changes spanning several tables
+	pool *pgxpool.Pool
+}
+
+func NewRepository(db *db.Queries, pool *pgxpool.Pool) *repository {
+	return &repository{Queries: db, pool: pool}
+}
+
+func (r *repository) SetRole(ctx context.Context, roomId uuid.UUID, username string, role string) error {
+	_, err := r.UpsertRoomMember(ctx, db.UpsertRoomMemberParams{
+		RoomID:   pgtype.UUID{Bytes: roomId, Valid: true},
+		Username: username,
+		Role:     role})
+	return err
+}
+
+func (r *repository) GetRole(ctx context.Context, roomId uuid.UUID, username string) (string, error) {
+	member, err := r.GetRoomMember(ctx, db.GetRoomMemberParams{
+		RoomID:   pgtype.UUID{Bytes: roomId, Valid: true},
+		Username: username})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+
+	return member.Role, nil
+}
+
+func (r *repository) AddSanction(ctx context.Context, s sanction) (sanction, error) {
+	arg := db.CreateRoomSanctionParams{
+		RoomID:    pgtype.UUID{Bytes: s.RoomID, Valid: true},
+		Username:  s.Username,
+		Kind:      s.Kind,
+		Reason:    s.Reason,
+		CreatedBy: s.CreatedBy}
+	if s.ExpiresAt != nil {
+		arg.ExpiresAt = pgtype.Timestamptz{Time: *s.ExpiresAt, Valid: true}
+	}
+
+	rawSanction, err := r.CreateRoomSanction(ctx, arg)
+	if err != nil {
+		return sanction{}, err
+	}
+
+	return toSanction(rawSanction), nil
+}
+
+func (r *repository) ActiveSanction(ctx context.Context, roomId uuid.UUID, username string, kind string) (*sanction, error) {
+	rawSanction, err := r.GetActiveRoomSanction(ctx, db.GetActiveRoomSanctionParams{
+		RoomID:   pgtype.UUID{Bytes: roomId, Valid: true},
+		Username: username,
+		Kind:     kind})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	s := toSanction(rawSanction)
+	return &s, nil
+}
+
+func (r *repository) RemoveSanctions(ctx context.Context, roomId uuid.UUID, username string, kind string) (bool, error) {
+	removed, err := r.DeleteRoomSanctions(ctx, db.DeleteRoomSanctionsParams{
+		RoomID:   pgtype.UUID{Bytes: roomId, Valid: true},
+		Username: username,
+		Kind:     kind})
+	if err != nil {
+		return false, err
+	}
+
+	return removed > 0, nil
+}
+
+func toSanction(rawSanction db.RoomSanction) sanction {
+	s := sanction{
+		ID:        rawSanction.ID,
+		RoomID:    rawSanction.RoomID.Bytes,
+		Username:  rawSanction.Username,
+		Kind:      rawSanction.Kind,
+		Reason:    rawSanction.Reason,
+		CreatedBy: rawSanction.CreatedBy,
+		CreatedAt: rawSanction.CreatedAt.Time,
+	}
+	if rawSanction.ExpiresAt.Valid {
+		expiresAt := rawSanction.ExpiresAt.Time
+		s.ExpiresAt = &expiresAt
+	}
+	return s
+}
+
+func (r *repository) AddMember(ctx context.Context, roomId uuid.UUID, username string, role string) error {
+	return r.AddRoomMember(ctx, db.AddRoomMemberParams{
+		RoomID:   pgtype.UUID{Bytes: roomId, Valid: true},
+		Username: username,
+		Role:     role})
+}
+
+func (r *repository) CreateInvite(ctx context.Context, inv invite) (invite, error) {
+	rawInvite, err := r.CreateRoomInvite(ctx, db.CreateRoomInviteParams{
+		RoomID:    pgtype.UUID{Bytes: inv.RoomID, Valid: true},
+		Username:  inv.Username,
+		InvitedBy: inv.InvitedBy})
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == foreignKeyViolation {
+		return invite{}, errUserNotFound
+	}
+	if err != nil {
+		return invite{}, err
+	}
+
+	return toInvite(rawInvite), nil
+}
+
+func (r *repository) GetInvite(ctx context.Context, id int64) (*invite, error) {
+	rawInvite, err := r.GetRoomInvite(ctx, id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	inv := toInvite(rawInvite)
+	return &inv, nil
+}
+
+func (r *repository) UserInvites(ctx context.Context, username string) ([]invite, error) {
+	rawInvites, err := r.ListUserRoomInvites(ctx, username)
+	if err != nil {
+		return nil, err
+	}
+
+	invites := make([]invite, 0, len(rawInvites))
+	for _, rawInvite := range rawInvites {
+		invites = append(invites, toInvite(rawInvite))
+	}
+	return invites, nil
+}
+
+func (r *repository) DeleteInvite(ctx context.Context, id int64) error {
+	return r.DeleteRoomInvite(ctx, id)
+}
+
+func toInvite(rawInvite db.RoomInvite) invite {
+	return invite{
+		ID:        rawInvite.ID,
+		RoomID:    rawInvite.RoomID.Bytes,
+		Username:  rawInvite.Username,
+		InvitedBy: rawInvite.InvitedBy,
+		CreatedAt: rawInvite.CreatedAt.Time,
+	}
+}
+
+func (r *repository) AddConversation(ctx context.Context, roomId uuid.UUID, a string, b string) error {
+	a, b = dmPair(a, b)
+	err := r.AddDirectMessage(ctx, db.AddDirectMessageParams{
+		RoomID: pgtype.UUID{Bytes: roomId, Valid: true},
+		UserA:  a,
+		UserB:  b})
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == foreignKeyViolation {
+		return errUserNotFound
+	}
+	return err
+}
+
+func (r *repository) Conversations(ctx context.Context, username string) ([]conversation, error) {
+	rawDMs, err := r.ListUserDirectMessages(ctx, username)
+	if err != nil {
+		return nil, err
+	}
+
+	conversations := make([]conversation, 0, len(rawDMs))
+	for _, rawDM := range rawDMs {
+		with := rawDM.UserA
+		if with == username {
+			with = rawDM.UserB
+		}
+		conversations = append(conversations, conversation{
+			RoomID:    rawDM.RoomID.Bytes,
+			With:      with,
+			CreatedAt: rawDM.CreatedAt.Time,
+		})
+	}
+	return conversations, nil
+}
+
+// DeleteRoom removes everything stored about a room, all of it or nothing
+func (r *repository) DeleteRoom(ctx context.Context, roomId uuid.UUID) error {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	queries := r.WithTx(tx)
+	id := pgtype.UUID{Bytes: roomId, Valid: true}
+	deletes := []func(context.Context, pgtype.UUID) error{
+		queries.DeleteRoomMembers,
+		queries.DeleteRoomInvites,
+		queries.DeleteAllRoomSanctions,
+		queries.DeleteRoomAlerts,
+		queries.DeleteRoomSchedules,
+	}
+	for _, del := range deletes {
+		err := del(ctx, id)
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit(ctx)
+}
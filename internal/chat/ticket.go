@@ -0,0 +1,126 @@
+package chat
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"financial-chat-api/util/auth"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// browsers can't set an Authorization header on the upgrade, so they trade
+	// their token for a ticket and pass it in the ticket query param, or as a
+	// ticket.<ticket> subprotocol next to chat
+	ticketParam          = "ticket"
+	ticketProtocolPrefix = "ticket."
+	ticketTTL            = 30 * time.Second
+)
+
+var errInvalidTicket = errors.New("invalid or expired websocket ticket")
+
+// ticket stands for the token it was issued with, so the connection still
+// follows the expiry and revocation of that token
+type ticket struct {
+	payload   *auth.Payload
+	expiresAt time.Time
+}
+
+// tickets are single use and only kept in memory, like the rooms they open
+type tickets struct {
+	mu      sync.Mutex
+	tickets map[string]ticket
+}
+
+func newTickets() *tickets {
+	return &tickets{tickets: make(map[string]ticket)}
+}
+
+// issue returns a ticket for payload, it never outlives the token
+func (t *tickets) issue(payload *auth.Payload) (string, time.Time, error) {
+	b := make([]byte, 32)
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	value := base64.RawURLEncoding.EncodeToString(b)
+
+	now := time.Now()
+	expiresAt := now.Add(ticketTTL)
+	if payload.ExpiredAt.Before(expiresAt) {
+		expiresAt = payload.ExpiredAt
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	// unredeemed tickets are dropped here, issuing is rare enough
+	for v, tk := range t.tickets {
+		if now.After(tk.expiresAt) {
+			delete(t.tickets, v)
+		}
+	}
+	t.tickets[value] = ticket{payload: payload, expiresAt: expiresAt}
+
+	return value, expiresAt, nil
+}
+
+// redeem returns the payload of the ticket, which can't be used again
+func (t *tickets) redeem(value string) (*auth.Payload, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	tk, ok := t.tickets[value]
+	delete(t.tickets, value)
+	if !ok || time.Now().After(tk.expiresAt) {
+		return nil, errInvalidTicket
+	}
+
+	return tk.payload, nil
+}
+
+// revoke drops the tickets whose token matches, along with the connections of revoked sessions
+func (t *tickets) revoke(match func(payload *auth.Payload) bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for v, tk := range t.tickets {
+		if match(tk.payload) {
+			delete(t.tickets, v)
+		}
+	}
+}
+
+// wsTicket returns the ticket of the upgrade request, from the query or the subprotocols
+func wsTicket(r *http.Request) string {
+	if value := r.URL.Query().Get(ticketParam); value != "" {
+		return value
+	}
+
+	for _, header := range r.Header.Values("Sec-WebSocket-Protocol") {
+		for _, protocol := range strings.Split(header, ",") {
+			protocol = strings.TrimSpace(protocol)
+			if strings.HasPrefix(protocol, ticketProtocolPrefix) {
+				return strings.TrimPrefix(protocol, ticketProtocolPrefix)
+			}
+		}
+	}
+
+	return ""
+}
+
+// wsSubprotocols is what the upgrade can negotiate, chat when the client offers it, or
+// else the ticket subprotocol, a browser offering only that one fails without a match
+func wsSubprotocols(r *http.Request) []string {
+	protocols := []string{"chat"}
+	for _, header := range r.Header.Values("Sec-WebSocket-Protocol") {
+		for _, protocol := range strings.Split(header, ",") {
+			protocol = strings.TrimSpace(protocol)
+			if strings.HasPrefix(protocol, ticketProtocolPrefix) {
+				return append(protocols, protocol)
+			}
+		}
+	}
+	return protocols
+}
@@ -0,0 +1,48 @@
+package chat
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+const (
+	errCodeEmptyMessage   = "empty_message"
+	errCodeMessageTooLong = "message_too_long"
+)
+
+// normalizeMessage cleans up a message before it is broadcast: invalid utf-8,
+// control characters other than new lines and tabs, and the bidi overrides and
+// isolates that can disguise text are dropped, the text is NFC normalized and
+// trimmed. It returns an error code and reason when the result is empty or
+// longer than maxLength characters
+func normalizeMessage(msg string, maxLength int) (string, string, string) {
+	msg = strings.ToValidUTF8(msg, "")
+	msg = strings.Map(func(r rune) rune {
+		if r == '\n' || r == '\t' {
+			return r
+		}
+		if unicode.IsControl(r) || isBidiControl(r) {
+			return -1
+		}
+		return r
+	}, msg)
+	msg = strings.TrimSpace(norm.NFC.String(msg))
+
+	if msg == "" {
+		return "", errCodeEmptyMessage, "message is empty"
+	}
+	if maxLength > 0 && utf8.RuneCountInString(msg) > maxLength {
+		return "", errCodeMessageTooLong, fmt.Sprintf("message is longer than %d characters", maxLength)
+	}
+	return msg, "", ""
+}
+
+// isBidiControl reports whether r is an embedding, override or isolate. Other
+// format characters stay, the zero width joiners hold emoji sequences and some scripts together
+func isBidiControl(r rune) bool {
+	return (r >= '\u202a' && r <= '\u202e') || (r >= '\u2066' && r <= '\u2069')
+}
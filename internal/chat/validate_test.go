@@ -0,0 +1,34 @@
+package chat
+
+import "testing"
+
+func TestNormalizeMessage(t *testing.T) {
+	tests := []struct {
+		name string
+		msg  string
+		want string
+		code string
+	}{
+		{name: "plain", msg: "hello", want: "hello"},
+		{name: "trimmed", msg: "  hello\n", want: "hello"},
+		{name: "keeps new lines and tabs", msg: "a\n\tb", want: "a\n\tb"},
+		{name: "drops control characters", msg: "a\x00b\x1bc\u0085", want: "abc"},
+		{name: "drops invalid utf-8", msg: "a\xffb", want: "ab"},
+		{name: "drops bidi overrides", msg: "file\u202egnp.exe", want: "filegnp.exe"},
+		{name: "drops bidi isolates", msg: "\u2066a\u2069\u2067b\u2068", want: "ab"},
+		{name: "keeps zero width joiners", msg: "\U0001f469\u200d\U0001f4bb", want: "\U0001f469\u200d\U0001f4bb"},
+		{name: "keeps zero width non joiners", msg: "می\u200cخواهم", want: "می\u200cخواهم"},
+		{name: "NFC", msg: "e\u0301", want: "\u00e9"},
+		{name: "empty", msg: " \u202e\x00 ", code: errCodeEmptyMessage},
+		{name: "too long", msg: "abcdefghijklm", code: errCodeMessageTooLong},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, code, _ := normalizeMessage(tt.msg, 12)
+			if got != tt.want || code != tt.code {
+				t.Errorf("normalizeMessage(%q) = %q, %q, want %q, %q", tt.msg, got, code, tt.want, tt.code)
+			}
+		})
+	}
+}
@@ -0,0 +1,145 @@
+package schedule
+
+import (
+	"context"
+	"errors"
+	"financial-chat-api/util/cron"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const maxSymbols = 20
+
+var (
+	ErrInvalidTimezone = errors.New("invalid timezone")
+	ErrInvalidSymbols  = fmt.Errorf("a schedule needs between 1 and %d symbols", maxSymbols)
+	ErrRoomNotFound    = errors.New("room doesn't exist")
+	ErrForbidden       = errors.New("only the room owner can manage its schedules")
+	ErrPrivateRoom     = errors.New("this room is private, you need an invite")
+	ErrNotFound        = errors.New("schedule doesn't exist")
+)
+
+type Schedule struct {
+	ID        int64      `json:"id"`
+	RoomID    uuid.UUID  `json:"roomId"`
+	Owner     string     `json:"owner"`
+	Spec      string     `json:"spec"`
+	Timezone  string     `json:"timezone"`
+	Symbols   []string   `json:"symbols"`
+	CreatedAt time.Time  `json:"createdAt"`
+	LastRunAt *time.Time `json:"lastRunAt"`
+}
+
+type scheduleRepo interface {
+	Save(ctx context.Context, schedule Schedule) (Schedule, error)
+	ListByRoom(ctx context.Context, roomId uuid.UUID) ([]Schedule, error)
+	Delete(ctx context.Context, id int64, roomId uuid.UUID) (bool, error)
+}
+
+type rooms interface {
+	RoomOwner(roomId uuid.UUID) (string, error)
+	// CanAccess is false for users outside a private room
+	CanAccess(ctx context.Context, roomId uuid.UUID, username string) (bool, error)
+}
+
+type service struct {
+	repo  scheduleRepo
+	rooms rooms
+}
+
+func NewService(repo scheduleRepo, rooms rooms) *service {
+	return &service{repo: repo, rooms: rooms}
+}
+
+type createScheduleReq struct {
+	Spec     string   `json:"spec"`
+	Timezone string   `json:"timezone"`
+	Symbols  []string `json:"symbols"`
+}
+
+func (s *service) Create(ctx context.Context, username string, roomId uuid.UUID, req createScheduleReq) (Schedule, error) {
+	err := s.checkOwner(username, roomId)
+	if err != nil {
+		return Schedule{}, err
+	}
+
+	_, err = cron.Parse(req.Spec)
+	if err != nil {
+		return Schedule{}, err
+	}
+
+	if req.Timezone == "" {
+		req.Timezone = "UTC"
+	}
+	_, err = time.LoadLocation(req.Timezone)
+	if err != nil {
+		return Schedule{}, fmt.Errorf("%w: %s", ErrInvalidTimezone, req.Timezone)
+	}
+
+	symbols := make([]string, 0, len(req.Symbols))
+	for _, symbol := range req.Symbols {
+		symbol = strings.ToLower(strings.TrimSpace(symbol))
+		if symbol == "" || strings.ContainsAny(symbol, " /,") {
+			return Schedule{}, fmt.Errorf("%w: invalid symbol %q", ErrInvalidSymbols, symbol)
+		}
+		symbols = append(symbols, symbol)
+	}
+	if len(symbols) == 0 || len(symbols) > maxSymbols {
+		return Schedule{}, ErrInvalidSymbols
+	}
+
+	return s.repo.Save(ctx, Schedule{
+		RoomID:   roomId,
+		Owner:    username,
+		Spec:     strings.Join(strings.Fields(req.Spec), " "),
+		Timezone: req.Timezone,
+		Symbols:  symbols,
+	})
+}
+
+func (s *service) List(ctx context.Context, username string, roomId uuid.UUID) ([]Schedule, error) {
+	_, err := s.rooms.RoomOwner(roomId)
+	if err != nil {
+		return nil, ErrRoomNotFound
+	}
+
+	ok, err := s.rooms.CanAccess(ctx, roomId, username)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrPrivateRoom
+	}
+
+	return s.repo.ListByRoom(ctx, roomId)
+}
+
+func (s *service) Delete(ctx context.Context, username string, roomId uuid.UUID, id int64) error {
+	err := s.checkOwner(username, roomId)
+	if err != nil {
+		return err
+	}
+
+	deleted, err := s.repo.Delete(ctx, id, roomId)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (s *service) checkOwner(username string, roomId uuid.UUID) error {
+	owner, err := s.rooms.RoomOwner(roomId)
+	if err != nil {
+		return ErrRoomNotFound
+	}
+	if owner != username {
+		return ErrForbidden
+	}
+	return nil
+}
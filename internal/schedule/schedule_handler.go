@@ -0,0 +1,107 @@
+package schedule
+
+import (
+	"context"
+	"errors"
+	"financial-chat-api/util/auth"
+	"financial-chat-api/util/cron"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/tomiok/webh"
+)
+
+type scheduleService interface {
+	Create(ctx context.Context, username string, roomId uuid.UUID, req createScheduleReq) (Schedule, error)
+	List(ctx context.Context, username string, roomId uuid.UUID) ([]Schedule, error)
+	Delete(ctx context.Context, username string, roomId uuid.UUID, id int64) error
+}
+
+type handler struct {
+	service scheduleService
+}
+
+func NewHandler(service scheduleService) *handler {
+	return &handler{service: service}
+}
+
+func (h *handler) Create(w http.ResponseWriter, r *http.Request) error {
+	roomId, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		return webh.ErrHTTP{Code: http.StatusBadRequest, Message: "roomId must be uuid"}
+	}
+
+	var req createScheduleReq
+	_, err = webh.DJson(r.Body, &req)
+	if err != nil {
+		return webh.ErrHTTP{Code: http.StatusBadRequest, Message: err.Error()}
+	}
+
+	authPayload := r.Context().Value(auth.AuthorizationPayloadCtxKey).(*auth.Payload)
+
+	res, err := h.service.Create(r.Context(), authPayload.Username, roomId, req)
+	if err != nil {
+		return toErrHTTP(err)
+	}
+
+	err = webh.EJson(w, res)
+	if err != nil {
+		return webh.ErrHTTP{Code: http.StatusInternalServerError, Message: err.Error()}
+	}
+	return nil
+}
+
+func (h *handler) List(w http.ResponseWriter, r *http.Request) error {
+	roomId, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		return webh.ErrHTTP{Code: http.StatusBadRequest, Message: "roomId must be uuid"}
+	}
+
+	authPayload := r.Context().Value(auth.AuthorizationPayloadCtxKey).(*auth.Payload)
+
+	res, err := h.service.List(r.Context(), authPayload.Username, roomId)
+	if err != nil {
+		return toErrHTTP(err)
+	}
+
+	err = webh.EJson(w, res)
+	if err != nil {
+		return webh.ErrHTTP{Code: http.StatusInternalServerError, Message: err.Error()}
+	}
+	return nil
+}
+
+func (h *handler) Delete(w http.ResponseWriter, r *http.Request) error {
+	roomId, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		return webh.ErrHTTP{Code: http.StatusBadRequest, Message: "roomId must be uuid"}
+	}
+	id, err := strconv.ParseInt(chi.URLParam(r, "scheduleId"), 10, 64)
+	if err != nil {
+		return webh.ErrHTTP{Code: http.StatusBadRequest, Message: "scheduleId must be a number"}
+	}
+
+	authPayload := r.Context().Value(auth.AuthorizationPayloadCtxKey).(*auth.Payload)
+
+	err = h.service.Delete(r.Context(), authPayload.Username, roomId, id)
+	if err != nil {
+		return toErrHTTP(err)
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+func toErrHTTP(err error) error {
+	switch {
+	case errors.Is(err, cron.ErrInvalidSpec), errors.Is(err, ErrInvalidTimezone), errors.Is(err, ErrInvalidSymbols):
+		return webh.ErrHTTP{Code: http.StatusBadRequest, Message: err.Error()}
+	case errors.Is(err, ErrForbidden), errors.Is(err, ErrPrivateRoom):
+		return webh.ErrHTTP{Code: http.StatusForbidden, Message: err.Error()}
+	case errors.Is(err, ErrRoomNotFound), errors.Is(err, ErrNotFound):
+		return webh.ErrHTTP{Code: http.StatusNotFound, Message: err.Error()}
+	}
+	return webh.ErrHTTP{Code: http.StatusInternalServerError, Message: err.Error()}
+}
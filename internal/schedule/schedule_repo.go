@@ -0,0 +1,73 @@
+package schedule
+
+import (
+	"context"
+	db "financial-chat-api/db/sqlc"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+type repository struct {
+	*db.Queries
+}
+
+func NewRepository(db *db.Queries) *repository {
+	return &repository{Queries: db}
+}
+
+func (r *repository) Save(ctx context.Context, schedule Schedule) (Schedule, error) {
+	arg := db.CreateScheduleParams{
+		RoomID:   pgtype.UUID{Bytes: schedule.RoomID, Valid: true},
+		Owner:    schedule.Owner,
+		Spec:     schedule.Spec,
+		Timezone: schedule.Timezone,
+		Symbols:  schedule.Symbols}
+
+	rawSchedule, err := r.CreateSchedule(ctx, arg)
+	if err != nil {
+		return Schedule{}, err
+	}
+
+	return toSchedule(rawSchedule), nil
+}
+
+func (r *repository) ListByRoom(ctx context.Context, roomId uuid.UUID) ([]Schedule, error) {
+	rawSchedules, err := r.ListRoomSchedules(ctx, pgtype.UUID{Bytes: roomId, Valid: true})
+	if err != nil {
+		return nil, err
+	}
+
+	schedules := make([]Schedule, len(rawSchedules))
+	for i, rawSchedule := range rawSchedules {
+		schedules[i] = toSchedule(rawSchedule)
+	}
+	return schedules, nil
+}
+
+func (r *repository) Delete(ctx context.Context, id int64, roomId uuid.UUID) (bool, error) {
+	deleted, err := r.DeleteSchedule(ctx, db.DeleteScheduleParams{
+		ID:     id,
+		RoomID: pgtype.UUID{Bytes: roomId, Valid: true}})
+	if err != nil {
+		return false, err
+	}
+
+	return deleted > 0, nil
+}
+
+func toSchedule(rawSchedule db.Schedule) Schedule {
+	schedule := Schedule{
+		ID:        rawSchedule.ID,
+		RoomID:    rawSchedule.RoomID.Bytes,
+		Owner:     rawSchedule.Owner,
+		Spec:      rawSchedule.Spec,
+		Timezone:  rawSchedule.Timezone,
+		Symbols:   rawSchedule.Symbols,
+		CreatedAt: rawSchedule.CreatedAt.Time,
+	}
+	if rawSchedule.LastRunAt.Valid {
+		schedule.LastRunAt = &rawSchedule.LastRunAt.Time
+	}
+	return schedule
+}
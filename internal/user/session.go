@@ -0,0 +1,188 @@
+package user
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"financial-chat-api/util/auth"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	errInvalidRefreshToken = errors.New("invalid refresh token")
+	errExpiredRefreshToken = errors.New("refresh token has expired")
+	errSessionRevoked      = errors.New("session has been revoked")
+	errRefreshTokenReused  = errors.New("refresh token was already used, the session has been revoked")
+)
+
+// session is a refresh token, rotating it creates a new session in the same family
+type session struct {
+	ID        uuid.UUID
+	FamilyID  uuid.UUID
+	Username  string
+	ExpiresAt time.Time
+	RotatedAt *time.Time
+	RevokedAt *time.Time
+	// AccessToken is the payload of the access token issued along the refresh token
+	AccessToken *auth.Payload
+}
+
+type tokens struct {
+	AccessToken           string    `json:"accessToken"`
+	AccessTokenExpiresAt  time.Time `json:"accessTokenExpiresAt"`
+	RefreshToken          string    `json:"refreshToken"`
+	RefreshTokenExpiresAt time.Time `json:"refreshTokenExpiresAt"`
+}
+
+type refreshReq struct {
+	RefreshToken string `json:"refreshToken"`
+}
+
+// Refresh trades a refresh token for a new access and refresh token. A refresh
+// token can only be used once, presenting it again means it leaked, so the whole
+// family is revoked and the user has to log in again
+func (s *service) Refresh(ctx context.Context, req refreshReq) (tokens, error) {
+	if req.RefreshToken == "" {
+		return tokens{}, errInvalidRefreshToken
+	}
+
+	sess, err := s.repo.SessionByToken(ctx, hashToken(req.RefreshToken))
+	if err != nil {
+		return tokens{}, err
+	}
+	if sess == nil {
+		return tokens{}, errInvalidRefreshToken
+	}
+	if sess.RevokedAt != nil {
+		return tokens{}, errSessionRevoked
+	}
+	if sess.RotatedAt != nil {
+		return tokens{}, s.revokeReused(ctx, sess)
+	}
+	if time.Now().After(sess.ExpiresAt) {
+		return tokens{}, errExpiredRefreshToken
+	}
+
+	// two requests racing with the same token, only one of them rotates it
+	rotated, err := s.repo.RotateSession(ctx, sess.ID)
+	if err != nil {
+		return tokens{}, err
+	}
+	if !rotated {
+		return tokens{}, s.revokeReused(ctx, sess)
+	}
+
+	return s.issue(ctx, sess.Username, sess.FamilyID)
+}
+
+func (s *service) revokeReused(ctx context.Context, sess *session) error {
+	log.Printf("refresh token reuse for %s, revoking session family %s", sess.Username, sess.FamilyID)
+	_, err := s.revokeFamily(ctx, sess.FamilyID)
+	if err != nil {
+		return err
+	}
+	return errRefreshTokenReused
+}
+
+// revokeFamily revokes the refresh tokens of the family, along with the access
+// tokens issued with them, and closes the websockets opened with those
+func (s *service) revokeFamily(ctx context.Context, familyId uuid.UUID) ([]uuid.UUID, error) {
+	tokenIds, err := s.repo.RevokeFamily(ctx, familyId)
+	if err != nil {
+		return nil, err
+	}
+
+	s.connections.DisconnectTokens(tokenIds)
+	return tokenIds, nil
+}
+
+// Logout revokes the session of the access token, every token issued since its login stops working
+func (s *service) Logout(ctx context.Context, payload *auth.Payload) error {
+	// the token itself is revoked even when it has no session, like tokens from before sessions existed
+	err := s.repo.RevokeToken(ctx, payload)
+	if err != nil {
+		return err
+	}
+	s.connections.DisconnectTokens([]uuid.UUID{payload.ID})
+
+	sess, err := s.repo.SessionByAccessToken(ctx, payload.ID)
+	if err != nil {
+		return err
+	}
+	if sess == nil {
+		return nil
+	}
+
+	_, err = s.revokeFamily(ctx, sess.FamilyID)
+	return err
+}
+
+// RevokeUser logs username out everywhere, the next request needs a new login
+func (s *service) RevokeUser(ctx context.Context, username string) error {
+	err := s.repo.RevokeUser(ctx, username)
+	if err != nil {
+		return err
+	}
+
+	s.connections.DisconnectUser(username)
+	return nil
+}
+
+// issue creates an access token and a refresh token in familyId
+func (s *service) issue(ctx context.Context, username string, familyId uuid.UUID) (tokens, error) {
+	accessToken, payload, err := s.tokenMaker.CreateToken(username, s.accessTokenDuration)
+	if err != nil {
+		return tokens{}, err
+	}
+
+	refreshToken, err := newRefreshToken()
+	if err != nil {
+		return tokens{}, err
+	}
+
+	id, err := uuid.NewRandom()
+	if err != nil {
+		return tokens{}, err
+	}
+
+	sess, err := s.repo.CreateSession(ctx, session{
+		ID:          id,
+		FamilyID:    familyId,
+		Username:    username,
+		ExpiresAt:   time.Now().Add(s.refreshTokenDuration),
+		AccessToken: payload,
+	}, hashToken(refreshToken))
+	if err != nil {
+		return tokens{}, err
+	}
+
+	return tokens{
+		AccessToken:           accessToken,
+		AccessTokenExpiresAt:  payload.ExpiredAt,
+		RefreshToken:          refreshToken,
+		RefreshTokenExpiresAt: sess.ExpiresAt,
+	}, nil
+}
+
+// newRefreshToken returns an opaque random token, unlike access tokens it means
+// nothing without its session row
+func newRefreshToken() (string, error) {
+	b := make([]byte, 32)
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// hashToken is what gets stored, a leaked sessions table doesn't leak usable tokens
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
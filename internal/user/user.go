@@ -2,7 +2,10 @@ package user
 
 import (
 	"context"
+	"financial-chat-api/util/auth"
 	"time"
+
+	"github.com/google/uuid"
 )
 
 type User struct {
@@ -14,6 +17,13 @@ type User struct {
 type userRepo interface {
 	Save(ctx context.Context, user User) (User, error)
 	GetByUsername(ctx context.Context, username string) (User, error)
+	CreateSession(ctx context.Context, s session, tokenHash string) (session, error)
+	SessionByToken(ctx context.Context, tokenHash string) (*session, error)
+	SessionByAccessToken(ctx context.Context, tokenId uuid.UUID) (*session, error)
+	RotateSession(ctx context.Context, id uuid.UUID) (bool, error)
+	RevokeFamily(ctx context.Context, familyId uuid.UUID) ([]uuid.UUID, error)
+	RevokeUser(ctx context.Context, username string) error
+	RevokeToken(ctx context.Context, payload *auth.Payload) error
 }
 
 type hashPassword func(password string) (string, error)
@@ -21,26 +31,41 @@ type hashPassword func(password string) (string, error)
 type checkPassword func(password string, hashedPassword string) error
 
 type tokenMaker interface {
-	CreateToken(username string, duration time.Duration) (string, error)
+	CreateToken(username string, duration time.Duration) (string, *auth.Payload, error)
+}
+
+// connections are the open websockets, closed when their session is revoked
+type connections interface {
+	DisconnectTokens(tokenIds []uuid.UUID)
+	DisconnectUser(username string)
 }
 
 type service struct {
-	repo          userRepo
-	hashPassword  hashPassword
-	checkPassword checkPassword
-	tokenMaker    tokenMaker
+	repo                 userRepo
+	hashPassword         hashPassword
+	checkPassword        checkPassword
+	tokenMaker           tokenMaker
+	connections          connections
+	accessTokenDuration  time.Duration
+	refreshTokenDuration time.Duration
 }
 
 func NewService(
 	repo userRepo,
 	hashPassword hashPassword,
 	checkPassword checkPassword,
-	tokenMaker tokenMaker) *service {
+	tokenMaker tokenMaker,
+	connections connections,
+	accessTokenDuration time.Duration,
+	refreshTokenDuration time.Duration) *service {
 	return &service{
-		repo:          repo,
-		hashPassword:  hashPassword,
-		checkPassword: checkPassword,
-		tokenMaker:    tokenMaker}
+		repo:                 repo,
+		hashPassword:         hashPassword,
+		checkPassword:        checkPassword,
+		tokenMaker:           tokenMaker,
+		connections:          connections,
+		accessTokenDuration:  accessTokenDuration,
+		refreshTokenDuration: refreshTokenDuration}
 }
 
 type createUserReq struct {
@@ -69,8 +94,8 @@ type loginReq struct {
 }
 
 type loginRes struct {
-	AccessToken string `json:"accessToken"`
-	User        User   `json:"user"`
+	tokens
+	User User `json:"user"`
 }
 
 func (s *service) Login(ctx context.Context, req loginReq) (loginRes, error) {
@@ -84,10 +109,16 @@ func (s *service) Login(ctx context.Context, req loginReq) (loginRes, error) {
 		return loginRes{}, err
 	}
 
-	token, err := s.tokenMaker.CreateToken(user.Username, 15*time.Minute)
+	// every login starts a new session family
+	familyId, err := uuid.NewRandom()
+	if err != nil {
+		return loginRes{}, err
+	}
+
+	tokens, err := s.issue(ctx, user.Username, familyId)
 	if err != nil {
 		return loginRes{}, err
 	}
 
-	return loginRes{AccessToken: token, User: user}, nil
+	return loginRes{tokens: tokens, User: user}, nil
 }
@@ -2,14 +2,20 @@ package user
 
 import (
 	"context"
+	"errors"
+	"financial-chat-api/util/auth"
 	"net/http"
 
+	"github.com/go-chi/chi/v5"
 	"github.com/tomiok/webh"
 )
 
 type userService interface {
 	Create(ctx context.Context, req createUserReq) (User, error)
 	Login(ctx context.Context, req loginReq) (loginRes, error)
+	Refresh(ctx context.Context, req refreshReq) (tokens, error)
+	Logout(ctx context.Context, payload *auth.Payload) error
+	RevokeUser(ctx context.Context, username string) error
 }
 
 type handler struct {
@@ -59,3 +65,55 @@ func (h *handler) Login(w http.ResponseWriter, r *http.Request) error {
 
 	return nil
 }
+
+func (h *handler) Refresh(w http.ResponseWriter, r *http.Request) error {
+	var req refreshReq
+	_, err := webh.DJson(r.Body, &req)
+	if err != nil {
+		return webh.ErrHTTP{Code: 400, Message: err.Error()}
+	}
+
+	res, err := h.service.Refresh(r.Context(), req)
+	if err != nil {
+		return toErrHTTP(err)
+	}
+
+	err = webh.EJson(w, res)
+	if err != nil {
+		return webh.ErrHTTP{Code: 400, Message: err.Error()}
+	}
+
+	return nil
+}
+
+func (h *handler) Logout(w http.ResponseWriter, r *http.Request) error {
+	authPayload := r.Context().Value(auth.AuthorizationPayloadCtxKey).(*auth.Payload)
+
+	err := h.service.Logout(r.Context(), authPayload)
+	if err != nil {
+		return toErrHTTP(err)
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+// RevokeSessions logs out the user in the path from every device, admins only
+func (h *handler) RevokeSessions(w http.ResponseWriter, r *http.Request) error {
+	err := h.service.RevokeUser(r.Context(), chi.URLParam(r, "username"))
+	if err != nil {
+		return toErrHTTP(err)
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+func toErrHTTP(err error) error {
+	switch {
+	case errors.Is(err, errInvalidRefreshToken), errors.Is(err, errExpiredRefreshToken),
+		errors.Is(err, errSessionRevoked), errors.Is(err, errRefreshTokenReused):
+		return webh.ErrHTTP{Code: http.StatusUnauthorized, Message: err.Error()}
+	}
+	return webh.ErrHTTP{Code: http.StatusInternalServerError, Message: err.Error()}
+}
@@ -2,7 +2,13 @@ package user
 
 import (
 	"context"
+	"errors"
 	db "financial-chat-api/db/sqlc"
+	"financial-chat-api/util/auth"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
 )
 
 type repository struct {
@@ -42,3 +48,126 @@ func (r *repository) GetByUsername(ctx context.Context, username string) (User,
 		CreatedAt:      rawUser.CreatedAt.Time,
 	}, nil
 }
+
+func (r *repository) CreateSession(ctx context.Context, s session, tokenHash string) (session, error) {
+	arg := db.CreateSessionParams{
+		ID:        pgtype.UUID{Bytes: s.ID, Valid: true},
+		FamilyID:  pgtype.UUID{Bytes: s.FamilyID, Valid: true},
+		Username:  s.Username,
+		TokenHash: tokenHash,
+		ExpiresAt: pgtype.Timestamptz{Time: s.ExpiresAt, Valid: true}}
+	if s.AccessToken != nil {
+		arg.AccessTokenID = pgtype.UUID{Bytes: s.AccessToken.ID, Valid: true}
+		arg.AccessTokenExpiresAt = pgtype.Timestamptz{Time: s.AccessToken.ExpiredAt, Valid: true}
+	}
+
+	rawSession, err := r.Queries.CreateSession(ctx, arg)
+	if err != nil {
+		return session{}, err
+	}
+
+	return toSession(rawSession), nil
+}
+
+// SessionByToken returns nil when no session has the token
+func (r *repository) SessionByToken(ctx context.Context, tokenHash string) (*session, error) {
+	rawSession, err := r.GetSessionByTokenHash(ctx, tokenHash)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	s := toSession(rawSession)
+	return &s, nil
+}
+
+// SessionByAccessToken returns nil when the token wasn't issued with a session
+func (r *repository) SessionByAccessToken(ctx context.Context, tokenId uuid.UUID) (*session, error) {
+	rawSession, err := r.GetSessionByAccessToken(ctx, pgtype.UUID{Bytes: tokenId, Valid: true})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	s := toSession(rawSession)
+	return &s, nil
+}
+
+// RotateSession marks the session as used, false means it was already used or revoked
+func (r *repository) RotateSession(ctx context.Context, id uuid.UUID) (bool, error) {
+	rows, err := r.Queries.RotateSession(ctx, pgtype.UUID{Bytes: id, Valid: true})
+	if err != nil {
+		return false, err
+	}
+	return rows > 0, nil
+}
+
+// RevokeFamily revokes the sessions of the family and returns the ids of the access tokens it revoked
+func (r *repository) RevokeFamily(ctx context.Context, familyId uuid.UUID) ([]uuid.UUID, error) {
+	id := pgtype.UUID{Bytes: familyId, Valid: true}
+	rawIds, err := r.RevokeFamilyAccessTokens(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	err = r.RevokeSessionFamily(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	tokenIds := make([]uuid.UUID, len(rawIds))
+	for i, rawId := range rawIds {
+		tokenIds[i] = rawId.Bytes
+	}
+	return tokenIds, nil
+}
+
+func (r *repository) RevokeUser(ctx context.Context, username string) error {
+	_, err := r.RevokeUserAccessTokens(ctx, username)
+	if err != nil {
+		return err
+	}
+
+	return r.RevokeUserSessions(ctx, username)
+}
+
+func (r *repository) RevokeToken(ctx context.Context, payload *auth.Payload) error {
+	return r.Queries.RevokeToken(ctx, db.RevokeTokenParams{
+		ID:        pgtype.UUID{Bytes: payload.ID, Valid: true},
+		Username:  payload.Username,
+		ExpiresAt: pgtype.Timestamptz{Time: payload.ExpiredAt, Valid: true}})
+}
+
+// IsRevoked makes the repository the revocation store of auth.Verifier
+func (r *repository) IsRevoked(ctx context.Context, tokenId uuid.UUID) (bool, error) {
+	return r.Queries.IsTokenRevoked(ctx, pgtype.UUID{Bytes: tokenId, Valid: true})
+}
+
+func toSession(rawSession db.Session) session {
+	s := session{
+		ID:        rawSession.ID.Bytes,
+		FamilyID:  rawSession.FamilyID.Bytes,
+		Username:  rawSession.Username,
+		ExpiresAt: rawSession.ExpiresAt.Time,
+	}
+	if rawSession.AccessTokenID.Valid {
+		s.AccessToken = &auth.Payload{
+			ID:        rawSession.AccessTokenID.Bytes,
+			Username:  rawSession.Username,
+			ExpiredAt: rawSession.AccessTokenExpiresAt.Time,
+		}
+	}
+	if rawSession.RotatedAt.Valid {
+		rotatedAt := rawSession.RotatedAt.Time
+		s.RotatedAt = &rotatedAt
+	}
+	if rawSession.RevokedAt.Valid {
+		revokedAt := rawSession.RevokedAt.Time
+		s.RevokedAt = &revokedAt
+	}
+	return s
+}
@@ -0,0 +1,117 @@
+package watchlist
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+const (
+	DefaultName = "default"
+	// maxSymbols keeps /watch from fanning out into a burst of quote requests
+	maxSymbols = 20
+)
+
+var (
+	ErrInvalidSymbol = errors.New("invalid symbol")
+	ErrInvalidName   = errors.New("invalid watchlist name")
+	ErrNotFound      = errors.New("symbol is not in the watchlist")
+	ErrFull          = fmt.Errorf("a watchlist holds up to %d symbols", maxSymbols)
+)
+
+var (
+	symbolPattern = regexp.MustCompile(`^[a-z0-9^][a-z0-9._^-]{0,31}$`)
+	namePattern   = regexp.MustCompile(`^[a-z0-9_-]{1,32}$`)
+)
+
+type Watchlist struct {
+	Name    string   `json:"name"`
+	Symbols []string `json:"symbols"`
+}
+
+type watchlistRepo interface {
+	Add(ctx context.Context, username, name, symbol string) error
+	Remove(ctx context.Context, username, name, symbol string) (bool, error)
+	Get(ctx context.Context, username, name string) (Watchlist, error)
+	ListByUsername(ctx context.Context, username string) ([]Watchlist, error)
+}
+
+type service struct {
+	repo watchlistRepo
+}
+
+func NewService(repo watchlistRepo) *service {
+	return &service{repo: repo}
+}
+
+// Normalize lowercases and validates a watchlist name and symbol
+func Normalize(name, symbol string) (string, string, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if name == "" {
+		name = DefaultName
+	}
+	if !namePattern.MatchString(name) {
+		return "", "", ErrInvalidName
+	}
+
+	symbol = strings.ToLower(strings.TrimSpace(symbol))
+	if symbol != "" && !symbolPattern.MatchString(symbol) {
+		return "", "", ErrInvalidSymbol
+	}
+	return name, symbol, nil
+}
+
+func (s *service) List(ctx context.Context, username string) ([]Watchlist, error) {
+	return s.repo.ListByUsername(ctx, username)
+}
+
+func (s *service) Get(ctx context.Context, username, name string) (Watchlist, error) {
+	name, _, err := Normalize(name, "")
+	if err != nil {
+		return Watchlist{}, err
+	}
+	return s.repo.Get(ctx, username, name)
+}
+
+func (s *service) Add(ctx context.Context, username, name, symbol string) (Watchlist, error) {
+	name, symbol, err := Normalize(name, symbol)
+	if err != nil {
+		return Watchlist{}, err
+	}
+	if symbol == "" {
+		return Watchlist{}, ErrInvalidSymbol
+	}
+
+	list, err := s.repo.Get(ctx, username, name)
+	if err != nil {
+		return Watchlist{}, err
+	}
+	if len(list.Symbols) >= maxSymbols && !slices.Contains(list.Symbols, symbol) {
+		return Watchlist{}, ErrFull
+	}
+
+	err = s.repo.Add(ctx, username, name, symbol)
+	if err != nil {
+		return Watchlist{}, err
+	}
+	return s.repo.Get(ctx, username, name)
+}
+
+func (s *service) Remove(ctx context.Context, username, name, symbol string) (Watchlist, error) {
+	name, symbol, err := Normalize(name, symbol)
+	if err != nil {
+		return Watchlist{}, err
+	}
+
+	removed, err := s.repo.Remove(ctx, username, name, symbol)
+	if err != nil {
+		return Watchlist{}, err
+	}
+	if !removed {
+		return Watchlist{}, ErrNotFound
+	}
+	return s.repo.Get(ctx, username, name)
+}
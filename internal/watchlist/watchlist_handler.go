@@ -0,0 +1,84 @@
+package watchlist
+
+import (
+	"context"
+	"errors"
+	"financial-chat-api/util/auth"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/tomiok/webh"
+)
+
+type watchlistService interface {
+	List(ctx context.Context, username string) ([]Watchlist, error)
+	Get(ctx context.Context, username, name string) (Watchlist, error)
+	Add(ctx context.Context, username, name, symbol string) (Watchlist, error)
+	Remove(ctx context.Context, username, name, symbol string) (Watchlist, error)
+}
+
+type handler struct {
+	service watchlistService
+}
+
+func NewHandler(service watchlistService) *handler {
+	return &handler{service: service}
+}
+
+func (h *handler) List(w http.ResponseWriter, r *http.Request) error {
+	res, err := h.service.List(r.Context(), username(r))
+	if err != nil {
+		return toErrHTTP(err)
+	}
+
+	return encode(w, res)
+}
+
+func (h *handler) Get(w http.ResponseWriter, r *http.Request) error {
+	res, err := h.service.Get(r.Context(), username(r), chi.URLParam(r, "name"))
+	if err != nil {
+		return toErrHTTP(err)
+	}
+
+	return encode(w, res)
+}
+
+func (h *handler) AddSymbol(w http.ResponseWriter, r *http.Request) error {
+	res, err := h.service.Add(r.Context(), username(r), chi.URLParam(r, "name"), chi.URLParam(r, "symbol"))
+	if err != nil {
+		return toErrHTTP(err)
+	}
+
+	return encode(w, res)
+}
+
+func (h *handler) RemoveSymbol(w http.ResponseWriter, r *http.Request) error {
+	res, err := h.service.Remove(r.Context(), username(r), chi.URLParam(r, "name"), chi.URLParam(r, "symbol"))
+	if err != nil {
+		return toErrHTTP(err)
+	}
+
+	return encode(w, res)
+}
+
+func username(r *http.Request) string {
+	return r.Context().Value(auth.AuthorizationPayloadCtxKey).(*auth.Payload).Username
+}
+
+func encode(w http.ResponseWriter, res any) error {
+	err := webh.EJson(w, res)
+	if err != nil {
+		return webh.ErrHTTP{Code: http.StatusInternalServerError, Message: err.Error()}
+	}
+	return nil
+}
+
+func toErrHTTP(err error) error {
+	switch {
+	case errors.Is(err, ErrInvalidName), errors.Is(err, ErrInvalidSymbol), errors.Is(err, ErrFull):
+		return webh.ErrHTTP{Code: http.StatusBadRequest, Message: err.Error()}
+	case errors.Is(err, ErrNotFound):
+		return webh.ErrHTTP{Code: http.StatusNotFound, Message: err.Error()}
+	}
+	return webh.ErrHTTP{Code: http.StatusInternalServerError, Message: err.Error()}
+}
@@ -0,0 +1,66 @@
+package watchlist
+
+import (
+	"context"
+	db "financial-chat-api/db/sqlc"
+)
+
+type repository struct {
+	*db.Queries
+}
+
+func NewRepository(db *db.Queries) *repository {
+	return &repository{Queries: db}
+}
+
+func (r *repository) Add(ctx context.Context, username, name, symbol string) error {
+	return r.AddWatchlistSymbol(ctx, db.AddWatchlistSymbolParams{
+		Username: username,
+		Name:     name,
+		Symbol:   symbol})
+}
+
+func (r *repository) Remove(ctx context.Context, username, name, symbol string) (bool, error) {
+	removed, err := r.RemoveWatchlistSymbol(ctx, db.RemoveWatchlistSymbolParams{
+		Username: username,
+		Name:     name,
+		Symbol:   symbol})
+	if err != nil {
+		return false, err
+	}
+
+	return removed > 0, nil
+}
+
+func (r *repository) Get(ctx context.Context, username, name string) (Watchlist, error) {
+	items, err := r.ListWatchlistItems(ctx, db.ListWatchlistItemsParams{
+		Username: username,
+		Name:     name})
+	if err != nil {
+		return Watchlist{}, err
+	}
+
+	watchlist := Watchlist{Name: name, Symbols: []string{}}
+	for _, item := range items {
+		watchlist.Symbols = append(watchlist.Symbols, item.Symbol)
+	}
+	return watchlist, nil
+}
+
+func (r *repository) ListByUsername(ctx context.Context, username string) ([]Watchlist, error) {
+	items, err := r.ListUserWatchlistItems(ctx, username)
+	if err != nil {
+		return nil, err
+	}
+
+	watchlists := []Watchlist{}
+	for _, item := range items {
+		// items come ordered by name, so a new name starts a new watchlist
+		if len(watchlists) == 0 || watchlists[len(watchlists)-1].Name != item.Name {
+			watchlists = append(watchlists, Watchlist{Name: item.Name})
+		}
+		last := &watchlists[len(watchlists)-1]
+		last.Symbols = append(last.Symbols, item.Symbol)
+	}
+	return watchlists, nil
+}
@@ -2,9 +2,13 @@ package auth
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"net/http"
+	"slices"
 	"strings"
 
+	"github.com/google/uuid"
 	"github.com/tomiok/webh"
 )
 
@@ -14,30 +18,76 @@ const (
 	AuthorizationPayloadCtxKey = "authPayload"
 )
 
-func Middleware(tokenMaker *PasetoMaker) func(next http.Handler) http.Handler {
+// Revoker reports whether a token was revoked before it expired, like on logout
+type Revoker interface {
+	IsRevoked(ctx context.Context, tokenId uuid.UUID) (bool, error)
+}
+
+// ErrRevocationCheck is a failure of the revocation store, not of the token
+var ErrRevocationCheck = errors.New("can't check if the token was revoked")
+
+// Verifier checks tokens are valid and not revoked, for the Authorization
+// header and for tokens sent over websockets
+type Verifier struct {
+	tokenMaker *PasetoMaker
+	revoker    Revoker
+}
+
+func NewVerifier(tokenMaker *PasetoMaker, revoker Revoker) *Verifier {
+	return &Verifier{tokenMaker: tokenMaker, revoker: revoker}
+}
+
+func (v *Verifier) Verify(ctx context.Context, token string) (*Payload, error) {
+	payload, err := v.tokenMaker.VerifyToken(token)
+	if err != nil {
+		return nil, err
+	}
+
+	revoked, err := v.revoker.IsRevoked(ctx, payload.ID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrRevocationCheck, err)
+	}
+	if revoked {
+		return nil, ErrRevokedToken
+	}
+
+	return payload, nil
+}
+
+// BearerToken returns the token in the Authorization header of r
+func BearerToken(r *http.Request) (string, error) {
+	authorizationHeader := r.Header.Get(authorizationHeaderKey)
+	if len(authorizationHeader) == 0 {
+		return "", errors.New("missing authentication header")
+	}
+
+	fields := strings.Fields(authorizationHeader)
+	if len(fields) < 2 {
+		return "", errors.New("invalid authorization header format")
+	}
+
+	authType := strings.ToLower(fields[0])
+	if authType != authorizationTypeBearer {
+		return "", errors.New("unsupported authorization type: " + authType)
+	}
+
+	return fields[1], nil
+}
+
+func Middleware(verifier *Verifier) func(next http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			authorizationHeader := r.Header.Get(authorizationHeaderKey)
-			if len(authorizationHeader) == 0 {
-				webh.ResponseErr(http.StatusUnauthorized, w, "missing authentication header", nil)
-				return
-			}
-
-			fields := strings.Fields(authorizationHeader)
-			if len(fields) < 2 {
-				webh.ResponseErr(http.StatusUnauthorized, w, "invalid authorization header format", nil)
+			accessToken, err := BearerToken(r)
+			if err != nil {
+				webh.ResponseErr(http.StatusUnauthorized, w, err.Error(), nil)
 				return
 			}
 
-			authType := strings.ToLower(fields[0])
-			if authType != authorizationTypeBearer {
-				webh.ResponseErr(http.StatusUnauthorized, w, "unsupported authorization type: "+authType, nil)
+			payload, err := verifier.Verify(r.Context(), accessToken)
+			if errors.Is(err, ErrRevocationCheck) {
+				webh.ResponseErr(http.StatusInternalServerError, w, err.Error(), nil)
 				return
 			}
-
-			accessToken := fields[1]
-
-			payload, err := tokenMaker.VerifyToken(accessToken)
 			if err != nil {
 				webh.ResponseErr(http.StatusUnauthorized, w, err.Error(), nil)
 				return
@@ -48,3 +98,18 @@ func Middleware(tokenMaker *PasetoMaker) func(next http.Handler) http.Handler {
 		})
 	}
 }
+
+// AdminOnly lets through the users in admins, it goes after Middleware
+func AdminOnly(admins []string) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			payload := r.Context().Value(AuthorizationPayloadCtxKey).(*Payload)
+			if !slices.Contains(admins, payload.Username) {
+				webh.ResponseErr(http.StatusForbidden, w, "admins only", nil)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
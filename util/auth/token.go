@@ -15,6 +15,7 @@ import (
 var (
 	ErrInvalidToken = errors.New("token is invalid")
 	ErrExpiredToken = errors.New("token has expired")
+	ErrRevokedToken = errors.New("token has been revoked")
 )
 
 type Payload struct {
@@ -60,22 +61,24 @@ func NewPasetoMaker(symmetricKey string) (*PasetoMaker, error) {
 	return pMaker, nil
 }
 
-// CreateToken create a new token for a specific username and duration
-func (pMaker *PasetoMaker) CreateToken(username string, duration time.Duration) (string, error) {
-	tokenId, err := uuid.NewRandom()
+// CreateToken create a new token for a specific username and duration, along with its payload
+func (pMaker *PasetoMaker) CreateToken(username string, duration time.Duration) (string, *Payload, error) {
+	payload, err := NewPayload(username, duration)
 	if err != nil {
-		return "", err
+		return "", nil, err
 	}
-	iat := time.Now()
-	exp := iat.Add(duration)
 	jsonToken := paseto.JSONToken{
-		Subject:    tokenId.String(),
-		IssuedAt:   iat,
-		Expiration: exp,
+		Subject:    payload.ID.String(),
+		IssuedAt:   payload.IssuedAt,
+		Expiration: payload.ExpiredAt,
 	}
 	jsonToken.Set("username", username)
 
-	return pMaker.paseto.Encrypt(pMaker.symmetricKey, jsonToken, nil)
+	token, err := pMaker.paseto.Encrypt(pMaker.symmetricKey, jsonToken, nil)
+	if err != nil {
+		return "", nil, err
+	}
+	return token, payload, nil
 }
 
 // VerifyToken checks if the token is valid or not
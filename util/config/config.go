@@ -3,6 +3,8 @@ package config
 import (
 	"log"
 	"os"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/joho/godotenv"
@@ -15,6 +17,30 @@ type Config struct {
 	TokenSymmetricKey   string
 	AccessTokenDuration time.Duration
 	RabbitUrl           string
+	BotPort             string
+	QuoteCacheTTL       time.Duration
+	BotWorkers          int
+	BotRequestTimeout   time.Duration
+	AlertPollInterval   time.Duration
+	MarketHolidaysFile  string
+	SymbolsFile         string
+	// chat rate limits, a per minute value of 0 disables the limit
+	MessagesPerMinute     int
+	MessageBurst          int
+	CommandsPerMinute     int
+	CommandBurst          int
+	RoomCommandsPerMinute int
+	RoomCommandBurst      int
+	MaxFrameBytes         int64
+	MaxMessageLength      int
+	// rooms without clients for RoomIdleTimeout are suspended until someone joins, 0 keeps them running
+	RoomIdleTimeout time.Duration
+	// refresh tokens are rotated on every use, a session expires after RefreshTokenDuration without refreshing
+	RefreshTokenDuration time.Duration
+	// AdminUsers can revoke the sessions of any user
+	AdminUsers []string
+	// AllowedOrigins are the host patterns of the web pages that can open websockets, like app.example.com or localhost:*
+	AllowedOrigins []string
 }
 
 func Load() *Config {
@@ -29,11 +55,79 @@ func Load() *Config {
 	}
 
 	return &Config{
-		DBDriver:            os.Getenv("DB_DRIVER"),
-		DBSource:            os.Getenv("DB_SOURCE"),
-		ServerPort:          os.Getenv("SERVER_PORT"),
-		TokenSymmetricKey:   os.Getenv("TOKEN_SYMMETRIC_KEY"),
-		AccessTokenDuration: accesTokenDuration,
-		RabbitUrl:           os.Getenv("RABBIT_URL")}
+		DBDriver:              os.Getenv("DB_DRIVER"),
+		DBSource:              os.Getenv("DB_SOURCE"),
+		ServerPort:            os.Getenv("SERVER_PORT"),
+		TokenSymmetricKey:     os.Getenv("TOKEN_SYMMETRIC_KEY"),
+		AccessTokenDuration:   accesTokenDuration,
+		RabbitUrl:             os.Getenv("RABBIT_URL"),
+		BotPort:               getEnv("BOT_PORT", "8081"),
+		QuoteCacheTTL:         getDuration("QUOTE_CACHE_TTL", time.Minute),
+		BotWorkers:            getInt("BOT_WORKERS", 4),
+		BotRequestTimeout:     getDuration("BOT_REQUEST_TIMEOUT", 5*time.Second),
+		AlertPollInterval:     getDuration("ALERT_POLL_INTERVAL", time.Minute),
+		MarketHolidaysFile:    getEnv("MARKET_HOLIDAYS_FILE", "data/market_holidays.csv"),
+		SymbolsFile:           getEnv("SYMBOLS_FILE", "data/symbols.csv"),
+		MessagesPerMinute:     getInt("MESSAGES_PER_MINUTE", 60),
+		MessageBurst:          getInt("MESSAGE_BURST", 10),
+		CommandsPerMinute:     getInt("COMMANDS_PER_MINUTE", 10),
+		CommandBurst:          getInt("COMMAND_BURST", 3),
+		RoomCommandsPerMinute: getInt("ROOM_COMMANDS_PER_MINUTE", 30),
+		RoomCommandBurst:      getInt("ROOM_COMMAND_BURST", 10),
+		MaxFrameBytes:         int64(getInt("MAX_FRAME_BYTES", 8192)),
+		MaxMessageLength:      getInt("MAX_MESSAGE_LENGTH", 1000),
+		RoomIdleTimeout:       getDuration("ROOM_IDLE_TIMEOUT", 10*time.Minute),
+		RefreshTokenDuration:  getDuration("REFRESH_TOKEN_DURATION", 24*time.Hour),
+		AdminUsers:            getList("ADMIN_USERS"),
+		AllowedOrigins:        getList("ALLOWED_ORIGINS")}
 
 }
+
+// getEnv returns the value of the env var key, or fallback when it is unset
+func getEnv(key, fallback string) string {
+	value, ok := os.LookupEnv(key)
+	if !ok || value == "" {
+		return fallback
+	}
+	return value
+}
+
+// getDuration parses the env var key as a duration, or returns fallback when it is unset
+func getDuration(key string, fallback time.Duration) time.Duration {
+	value, ok := os.LookupEnv(key)
+	if !ok || value == "" {
+		return fallback
+	}
+
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		log.Fatalf("error loading %s: %s", key, err)
+	}
+	return duration
+}
+
+// getList splits the comma separated env var key, it is empty when unset
+func getList(key string) []string {
+	var list []string
+	for _, value := range strings.Split(os.Getenv(key), ",") {
+		value = strings.TrimSpace(value)
+		if value != "" {
+			list = append(list, value)
+		}
+	}
+	return list
+}
+
+// getInt parses the env var key as an int, or returns fallback when it is unset
+func getInt(key string, fallback int) int {
+	value, ok := os.LookupEnv(key)
+	if !ok || value == "" {
+		return fallback
+	}
+
+	i, err := strconv.Atoi(value)
+	if err != nil {
+		log.Fatalf("error loading %s: %s", key, err)
+	}
+	return i
+}
@@ -0,0 +1,188 @@
+package cron
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		spec    string
+		wantErr bool
+	}{
+		{spec: "* * * * *"},
+		{spec: "5 16 * * mon-fri"},
+		{spec: "*/15 9-17 * * 1-5"},
+		{spec: "0,30 8 1,15 jan,JUL *"},
+		{spec: "0 0 * * 7"},
+		{spec: "10-50/20 * * * *"},
+		{spec: "5/10 * * * *"},
+		{spec: "", wantErr: true},
+		{spec: "* * * *", wantErr: true},
+		{spec: "* * * * * *", wantErr: true},
+		{spec: "60 * * * *", wantErr: true},
+		{spec: "* 24 * * *", wantErr: true},
+		{spec: "* * 0 * *", wantErr: true},
+		{spec: "* * 32 * *", wantErr: true},
+		{spec: "* * * 13 *", wantErr: true},
+		{spec: "* * * * 8", wantErr: true},
+		{spec: "* * * * funday", wantErr: true},
+		{spec: "30-10 * * * *", wantErr: true},
+		{spec: "*/0 * * * *", wantErr: true},
+		{spec: "*/x * * * *", wantErr: true},
+		{spec: "1,,2 * * * *", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		_, err := Parse(tt.spec)
+		if tt.wantErr {
+			if !errors.Is(err, ErrInvalidSpec) {
+				t.Errorf("Parse(%q) error = %v, want ErrInvalidSpec", tt.spec, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Parse(%q) unexpected error: %v", tt.spec, err)
+		}
+	}
+}
+
+func TestNext(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// in 2026 DST starts on march 8th, 02:00 jumps to 03:00, and ends on
+	// november 1st, 02:00 goes back to 01:00
+	est := time.FixedZone("EST", -5*60*60)
+	edt := time.FixedZone("EDT", -4*60*60)
+
+	tests := []struct {
+		name string
+		spec string
+		from time.Time
+		want time.Time
+	}{
+		{
+			name: "every minute",
+			spec: "* * * * *",
+			from: time.Date(2026, 10, 19, 10, 0, 30, 0, time.UTC),
+			want: time.Date(2026, 10, 19, 10, 1, 0, 0, time.UTC),
+		},
+		{
+			name: "strictly after from",
+			spec: "0 10 * * *",
+			from: time.Date(2026, 10, 19, 10, 0, 0, 0, time.UTC),
+			want: time.Date(2026, 10, 20, 10, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "step",
+			spec: "*/15 * * * *",
+			from: time.Date(2026, 10, 19, 10, 16, 0, 0, time.UTC),
+			want: time.Date(2026, 10, 19, 10, 30, 0, 0, time.UTC),
+		},
+		{
+			name: "weekdays skip the weekend",
+			spec: "5 16 * * mon-fri",
+			from: time.Date(2026, 10, 23, 17, 0, 0, 0, time.UTC),
+			want: time.Date(2026, 10, 26, 16, 5, 0, 0, time.UTC),
+		},
+		{
+			name: "7 is sunday",
+			spec: "0 0 * * 7",
+			from: time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC),
+			want: time.Date(2026, 10, 25, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "restricted day of month and day of week match either",
+			spec: "0 0 1 * mon",
+			from: time.Date(2026, 10, 20, 0, 0, 0, 0, time.UTC),
+			want: time.Date(2026, 10, 26, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "starred day of week only uses the day of month",
+			spec: "0 0 1 * *",
+			from: time.Date(2026, 10, 20, 0, 0, 0, 0, time.UTC),
+			want: time.Date(2026, 11, 1, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "month names roll the year",
+			spec: "0 8 15 jan *",
+			from: time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC),
+			want: time.Date(2027, 1, 15, 8, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "leap day",
+			spec: "0 0 29 feb *",
+			from: time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC),
+			want: time.Date(2028, 2, 29, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "never matches",
+			spec: "0 0 31 feb *",
+			from: time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC),
+			want: time.Time{},
+		},
+		{
+			name: "keeps the wall clock across DST start",
+			spec: "30 9 * * *",
+			from: time.Date(2026, 3, 7, 10, 0, 0, 0, newYork),
+			want: time.Date(2026, 3, 8, 9, 30, 0, 0, edt),
+		},
+		{
+			name: "keeps the wall clock across DST end",
+			spec: "30 9 * * *",
+			from: time.Date(2026, 10, 31, 10, 0, 0, 0, newYork),
+			want: time.Date(2026, 11, 1, 9, 30, 0, 0, est),
+		},
+		{
+			name: "time skipped by DST start runs after the jump",
+			spec: "30 2 * * *",
+			from: time.Date(2026, 3, 8, 1, 0, 0, 0, newYork),
+			want: time.Date(2026, 3, 8, 3, 30, 0, 0, edt),
+		},
+		{
+			name: "time skipped by DST start is back the next day",
+			spec: "30 2 * * *",
+			from: time.Date(2026, 3, 8, 3, 30, 0, 0, newYork),
+			want: time.Date(2026, 3, 9, 2, 30, 0, 0, edt),
+		},
+		{
+			name: "hourly across DST start",
+			spec: "0 * * * *",
+			from: time.Date(2026, 3, 8, 1, 30, 0, 0, newYork),
+			want: time.Date(2026, 3, 8, 3, 0, 0, 0, edt),
+		},
+		{
+			name: "repeated hour at DST end runs once",
+			spec: "30 1 * * *",
+			from: time.Date(2026, 11, 1, 1, 30, 0, 0, edt).In(newYork),
+			want: time.Date(2026, 11, 2, 1, 30, 0, 0, est),
+		},
+		{
+			name: "repeated hour at DST end from its second pass",
+			spec: "45 1 * * *",
+			from: time.Date(2026, 11, 1, 1, 30, 0, 0, est).In(newYork),
+			want: time.Date(2026, 11, 2, 1, 45, 0, 0, est),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := Parse(tt.spec)
+			if err != nil {
+				t.Fatalf("Parse(%q): %v", tt.spec, err)
+			}
+
+			got := s.Next(tt.from)
+			if !got.Equal(tt.want) {
+				t.Errorf("Next(%s) = %s, want %s", tt.from, got, tt.want)
+			}
+			if !got.IsZero() && got.Location() != tt.from.Location() {
+				t.Errorf("Next(%s) is in %s, want %s", tt.from, got.Location(), tt.from.Location())
+			}
+		})
+	}
+}